@@ -0,0 +1,127 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+)
+
+// Values of the "--group-by" flag
+const (
+	groupByUser        = "user"
+	groupByAffiliation = "affiliation"
+)
+
+// Variables set from the command line
+var groupBy string
+var affiliationsFileName string
+
+// Validates the "--group-by" flag (and the affiliations file it requires)
+func checkGroupByArgs() error {
+	switch strings.ToLower(groupBy) {
+	case groupByUser:
+		return nil
+	case groupByAffiliation:
+		if affiliationsFileName == "" {
+			return fmt.Errorf("The affiliations file must be specified with \"--affiliations\" to group by affiliation\n")
+		}
+		if !isFileValid(affiliationsFileName) {
+			return fmt.Errorf("Invalid affiliations file\n")
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s is an invalid grouping (should be \"%s\" or \"%s\")\n", groupBy, groupByUser, groupByAffiliation)
+	}
+}
+
+// Returns true if the users must be grouped by affiliation
+func isGroupedByAffiliation() bool {
+	return strings.ToLower(groupBy) == groupByAffiliation
+}
+
+// Loads the affiliations file: the organizations are the keys of the file (see readLoginMappingFile)
+func loadAffiliations(fileName string) ([]pivot.Affiliation, error) {
+	mappings, err := readLoginMappingFile(fileName, "organization")
+	if err != nil {
+		return nil, fmt.Errorf("Invalid affiliations file %s: %v", fileName, err)
+	}
+	var affiliations []pivot.Affiliation
+	for _, mapping := range mappings {
+		affiliations = append(affiliations, pivot.Affiliation{Login: mapping.Login, Organization: mapping.Key, From: mapping.From, To: mapping.To})
+	}
+	if err := pivot.ValidateAffiliations(affiliations); err != nil {
+		return nil, fmt.Errorf("Invalid affiliations file %s: %v", fileName, err)
+	}
+	return affiliations, nil
+}
+
+// Groups the users of the table by organization (see pivot.GroupByAffiliation). Returns the
+// table of the organizations and the kind of contribution to process it with.
+func groupByAffiliations(table *pivot.PivotTable, kind InputType) (*pivot.PivotTable, InputType, error) {
+	affiliations, err := loadAffiliations(affiliationsFileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The anonymized users appear in the table under their pseudonym
+	for i := range affiliations {
+		affiliations[i].Login = anonymizedLogin(affiliations[i].Login)
+	}
+	grouped, err := table.GroupByAffiliation(affiliations)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isVerboseExtract {
+		fmt.Fprintf(os.Stderr, "Grouped the %d users of \"%s\" into %d organizations\n", table.NbrOfUsers(), table.Source, grouped.NbrOfUsers())
+	}
+
+	organizationKind, err := kind.byOrganization(func(organization string) (*pivot.PivotTable, error) {
+		return table.Members(affiliations, organization)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return grouped, organizationKind, nil
+}
+
+// Returns the kind of contribution used to rank the organizations on the contributions of their members
+func (kind *ContributionKind) byOrganization(members func(organization string) (*pivot.PivotTable, error)) (*ContributionKind, error) {
+	organizationKind := &ContributionKind{
+		Name:                kind.Name + "_by_organization",
+		Label:               "Organization",
+		TotalColumn:         kind.TotalColumn,
+		CompareTotalColumn:  kind.CompareTotalColumn,
+		CompareStatusColumn: kind.CompareStatusColumn,
+		PlotDirectory:       kind.PlotDirectory + "Organizations",
+		PlotTitle:           kind.PlotTitle,
+		Title:               "Top " + kind.Label + " Organizations",
+		Introduction:        "Extraction of the {{.TopSize}} top organizations, counting the contributions of their " + kind.Name + ", \nover the {{.Period}} months before \"{{.EndMonth}}\".",
+		members:             members,
+	}
+	if err := organizationKind.complete(); err != nil {
+		return nil, err
+	}
+	return organizationKind, nil
+}
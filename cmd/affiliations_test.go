@@ -0,0 +1,111 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExecuteExtractGroupedByAffiliation_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := filepath.Join(tempDir, "organizations.md")
+	goldenMarkdownFilename, err := duplicateFile("../test_data/extract-affiliation_reference_output.md", tempDir)
+
+	assert.NoError(t, err, "Unexpected Golden File duplication error")
+	assert.NotEmpty(t, goldenMarkdownFilename, "Failure to duplicate Golden File")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/overview.csv", "--group-by=affiliation", "--affiliations=../test_data/affiliations.yaml", "--ranking", "--out=" + testOutputFilename})
+	defer func() {
+		groupBy, affiliationsFileName, isRankingOutput = groupByUser, "", false
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.NoError(t, isFileEquivalent(testOutputFilename, goldenMarkdownFilename))
+}
+
+func Test_ExecuteCompareGroupedByAffiliation_integrationTest(t *testing.T) {
+	tempDir := t.TempDir()
+	testOutputFilename := filepath.Join(tempDir, "organizations-compare.csv")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"compare", "../test_data/overview.csv", "--group-by=affiliation", "--affiliations=../test_data/affiliations.yaml", "--history", "--out=" + testOutputFilename})
+	defer func() {
+		groupBy, affiliationsFileName, isOutputHistory = groupByUser, "", false
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results: the organizations are ranked, with their history and stacked plots
+	assert.NoError(t, error, "Unexpected failure")
+	assert.FileExists(t, testOutputFilename)
+	assert.FileExists(t, filepath.Join(tempDir, "top_submitters_by_organization_evolution_fullHistory.csv"))
+	assert.FileExists(t, filepath.Join(tempDir, "plotOrganizations", "Linux Foundation.png"))
+	assert.FileExists(t, filepath.Join(tempDir, "plotOrganizations", organizationsPlotName+".png"))
+}
+
+func Test_checkGroupByArgs(t *testing.T) {
+	defer func() { groupBy, affiliationsFileName = groupByUser, "" }()
+
+	groupBy, affiliationsFileName = "user", ""
+	assert.NoError(t, checkGroupByArgs())
+
+	groupBy = "affiliation"
+	assert.Error(t, checkGroupByArgs(), "The affiliations file is required")
+
+	affiliationsFileName = "../test_data/affiliations.yaml"
+	assert.NoError(t, checkGroupByArgs())
+
+	groupBy = "company"
+	assert.Error(t, checkGroupByArgs())
+}
+
+func Test_loadAffiliations(t *testing.T) {
+	affiliations, err := loadAffiliations("../test_data/affiliations.yaml")
+
+	assert.NoError(t, err)
+	assert.Len(t, affiliations, 7)
+	var organizations []string
+	for _, affiliation := range affiliations {
+		if strings.EqualFold(affiliation.Login, "olblak") {
+			organizations = append(organizations, affiliation.Organization)
+		}
+	}
+	assert.Equal(t, []string{"CloudBees", "Linux Foundation"}, organizations)
+}
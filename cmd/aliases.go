@@ -0,0 +1,73 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+)
+
+// File mapping the aliases to the canonical logins (set from the command line or the configuration)
+var aliasesFileName string
+
+// The aliases loaded from the aliases file
+var aliases []pivot.Alias
+
+// Loads the aliases file specified with "--aliases" (if any). This is called by applyConfiguration.
+// The canonical logins are the keys of the file (see readLoginMappingFile).
+func loadAliases() error {
+	aliases = nil
+	if aliasesFileName == "" {
+		return nil
+	}
+
+	mappings, err := readLoginMappingFile(aliasesFileName, "canonical")
+	if err != nil {
+		return fmt.Errorf("Invalid aliases file %s: %v", aliasesFileName, err)
+	}
+	var loaded []pivot.Alias
+	for _, mapping := range mappings {
+		loaded = append(loaded, pivot.Alias{Login: mapping.Login, Canonical: mapping.Key, From: mapping.From, To: mapping.To})
+	}
+	if err := pivot.ValidateAliases(loaded); err != nil {
+		return fmt.Errorf("Invalid aliases file %s: %v", aliasesFileName, err)
+	}
+	aliases = loaded
+	return nil
+}
+
+// Merges the rows of the aliases into their canonical login
+func mergeAliases(table *pivot.PivotTable) (*pivot.PivotTable, error) {
+	if len(aliases) == 0 {
+		return table, nil
+	}
+	merged, mergedLogins, err := table.ApplyAliases(aliases)
+	if err != nil {
+		return nil, err
+	}
+	if len(mergedLogins) > 0 {
+		fmt.Fprintf(os.Stderr, "Merged %d aliases of \"%s\" into their canonical login%s\n", len(mergedLogins), table.Source, progressLogins(mergedLogins))
+	}
+	return merged, nil
+}
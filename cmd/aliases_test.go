@@ -0,0 +1,93 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExecuteExtractWithAliases_integrationTest(t *testing.T) {
+	aliasesFile := filepath.Join(t.TempDir(), "aliases.csv")
+	assert.NoError(t, os.WriteFile(aliasesFile, []byte("canonical,alias\nbasil,olblak\n"), 0644))
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/overview.csv", "--aliases=" + aliasesFile, "--out=-"})
+	defer func() {
+		aliasesFileName = ""
+		outputFileName = "top-submitters_YYYY-MM.csv"
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results: the PRs of olblak are counted for basil
+	assert.NoError(t, error, "Unexpected failure")
+	assert.True(t, strings.HasPrefix(actual.String(), "Submitter,Total_PRs\nbasil,1478\n"))
+	assert.NotContains(t, actual.String(), "olblak")
+}
+
+func Test_ExecuteExtractWithInvalidAliases(t *testing.T) {
+	aliasesFile := filepath.Join(t.TempDir(), "aliases.yaml")
+	assert.NoError(t, os.WriteFile(aliasesFile, []byte("basil: [olblak]\nolblak: [basil]\n"), 0644))
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/overview.csv", "--aliases=" + aliasesFile, "--out=-"})
+	defer func() {
+		aliasesFileName = ""
+		outputFileName = "top-submitters_YYYY-MM.csv"
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.Error(t, error, "Chained aliases should be rejected")
+}
+
+func Test_checkFileReport_aliasCollision(t *testing.T) {
+	aliases = []pivot.Alias{{Login: "olblak", Canonical: "basil"}}
+	defer func() { aliases = nil }()
+
+	_, report := checkFileReport("../test_data/overview.csv")
+
+	var collisions []pivot.Issue
+	for _, issue := range report.Issues {
+		if issue.Rule == pivot.RuleAliasCollision {
+			collisions = append(collisions, issue)
+		}
+	}
+	assert.Len(t, collisions, 1)
+	assert.False(t, report.HasErrors())
+}
@@ -0,0 +1,98 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+)
+
+// Anonymization settings (set from the command line or the configuration)
+var (
+	isAnonymizing  bool
+	optOutFileName string
+	pseudonymStyle string
+	pseudonymSalt  string
+	pseudonymizer  *pivot.Pseudonymizer
+)
+
+// Builds the pseudonymizer from the "--anonymize", "--opt-out", "--pseudonyms" and "--salt" flags.
+// This is called by applyConfiguration: the pseudonyms are assigned anew for every command.
+func loadAnonymization() error {
+	var optOut *pivot.AccountFilter
+	if optOutFileName != "" {
+		f, err := os.Open(optOutFileName)
+		if err != nil {
+			return fmt.Errorf("Unable to read the opt-out file: %v", err)
+		}
+		defer f.Close()
+		patterns, err := pivot.ReadAccountPatterns(f)
+		if err != nil {
+			return fmt.Errorf("Unable to read the opt-out file: %v", err)
+		}
+		if optOut, err = pivot.NewAccountFilter(patterns); err != nil {
+			return fmt.Errorf("Invalid opt-out file: %v", err)
+		}
+	}
+
+	p, err := pivot.NewPseudonymizer(pseudonymStyle, pseudonymSalt, isAnonymizing, optOut)
+	if err != nil {
+		return fmt.Errorf("Invalid anonymization: %v", err)
+	}
+	pseudonymizer = p
+	return nil
+}
+
+// Replaces the logins of the anonymized users of a table by their pseudonym
+func anonymizeUsers(table *pivot.PivotTable) (*pivot.PivotTable, error) {
+	anonymized, count, err := table.Anonymize(pseudonymizer)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		fmt.Fprintf(os.Stderr, "Anonymized %d users of \"%s\"\n", count, table.Source)
+	}
+	return anonymized, nil
+}
+
+// Returns the list of logins appended to a progress message, empty when anonymizing:
+// the logins of the tables are not anonymized yet when the aliases and the exclusions are applied.
+func progressLogins(logins []string) string {
+	if pseudonymizer.IsEnabled() {
+		return ""
+	}
+	return ": " + strings.Join(logins, ", ")
+}
+
+// Returns the name under which a login appears in the outputs: its pseudonym if it is anonymized
+func anonymizedLogin(login string) string {
+	if !pseudonymizer.IsAnonymized(login) {
+		return login
+	}
+	if pseudonym, found := pseudonymizer.Pseudonym(login); found {
+		return pseudonym
+	}
+	return login
+}
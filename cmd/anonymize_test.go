@@ -0,0 +1,135 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+	"github.com/stretchr/testify/assert"
+)
+
+// Resets the anonymization settings changed by a test
+func resetAnonymization() {
+	isAnonymizing, optOutFileName, pseudonymStyle, pseudonymSalt = false, "", pivot.PseudonymNumbered, ""
+	pseudonymizer = nil
+}
+
+func Test_ExecuteExtractAnonymized_integrationTest(t *testing.T) {
+	extract := func(extraArgs ...string) [][]string {
+		actual := new(bytes.Buffer)
+		rootCmd.SetOut(actual)
+		rootCmd.SetErr(actual)
+		rootCmd.SetArgs(append([]string{"extract", "../test_data/overview.csv", "--out=-"}, extraArgs...))
+		assert.NoError(t, rootCmd.Execute(), "Unexpected failure")
+		var records [][]string
+		for _, line := range strings.Split(strings.TrimSpace(actual.String()), "\n") {
+			records = append(records, strings.Split(line, ","))
+		}
+		return records
+	}
+	defer func() {
+		resetAnonymization()
+		outputFileName = "top-submitters_YYYY-MM.csv"
+	}()
+
+	plain := extract()
+	anonymized := extract("--anonymize")
+
+	// Only the users are replaced: the figures are the same
+	assert.Equal(t, len(plain), len(anonymized))
+	assert.Equal(t, []string{"Contributor #1", "1476"}, anonymized[1])
+	for i := 1; i < len(plain); i++ {
+		assert.Regexp(t, `^Contributor #[0-9]+$`, anonymized[i][0])
+		assert.Equal(t, plain[i][1], anonymized[i][1])
+	}
+}
+
+func Test_ExecuteCompareWithOptOut_integrationTest(t *testing.T) {
+	tempDir := t.TempDir()
+	optOutFile := filepath.Join(tempDir, "opt-out.txt")
+	assert.NoError(t, os.WriteFile(optOutFile, []byte("# Not to be named\nbasil\nolblak\n"), 0644))
+	outputFile := filepath.Join(tempDir, "top.md")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"compare", "../test_data/overview.csv", "--opt-out=" + optOutFile, "--exclude=olblak", "--history", "-o", outputFile})
+	defer func() {
+		resetAnonymization()
+		excludePatterns, isOutputHistory = nil, false
+		outputFileName = "top-submitters_YYYY-MM.csv"
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	markdown, err := os.ReadFile(outputFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(markdown), "| [Contributor #1](plot/Contributor%201.png) |      1476 |         |")
+	assert.Contains(t, string(markdown), "| [lemeurherve](plot/lemeurherve.png) |")
+	assert.NotContains(t, string(markdown), "basil")
+	assert.NotContains(t, string(markdown), "olblak", "An excluded user who opted out must not be named")
+
+	history, err := os.ReadFile(filepath.Join(tempDir, "top_submitters_evolution_fullHistory.csv"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(history), "\nContributor #1,")
+	assert.NotContains(t, string(history), "basil")
+	assert.FileExists(t, filepath.Join(tempDir, "plot", "Contributor 1.png"))
+	assert.NoFileExists(t, filepath.Join(tempDir, "plot", "basil.png"))
+}
+
+func Test_progressLogins(t *testing.T) {
+	defer resetAnonymization()
+
+	assert.Equal(t, ": basil, olblak", progressLogins([]string{"basil", "olblak"}))
+
+	// The logins must not be printed when anonymizing, even partially
+	isAnonymizing = true
+	assert.NoError(t, loadAnonymization())
+	assert.Equal(t, "", progressLogins([]string{"basil", "olblak"}))
+}
+
+func Test_ExecuteExtractHashedWithoutSalt(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/overview.csv", "--anonymize", "--pseudonyms=hashed", "--out=-"})
+	defer func() {
+		resetAnonymization()
+		outputFileName = "top-submitters_YYYY-MM.csv"
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.Error(t, error, "The hashed pseudonyms should require a salt")
+}
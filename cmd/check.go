@@ -22,41 +22,124 @@ THE SOFTWARE.
 package cmd
 
 import (
-	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"io"
 	"log"
 	"os"
-	"regexp"
-	"strconv"
+	"strings"
 
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
 	"github.com/spf13/cobra"
 )
 
 var isVerboseCheck bool
+var checkFormat string
+var isFixCheck bool
+var fixedOutputFileName string
+var isStrictCheck bool
+
+// Exit codes of the check command (0 means that the file can be processed)
+const (
+	checkExitErrors   = 1
+	checkExitWarnings = 2
+)
+
+// Rule reported when a structurally valid table doesn't contain enough data to be processed
+const ruleNotEnoughData = "not-enough-data"
 
 // checkCmd represents the check command
 var checkCmd = &cobra.Command{
 	Use:   "check [input file]",
 	Short: "Validates if input file has the correct format",
 	Long: `The CHECK command validates whether the input file is processable.
-It must absolutely be generated by the GNU "datamash" pivot function in
-order to be successfully processed.`,
+It must absolutely be generated by the GNU "datamash" pivot function (or by
+the INGEST command) in order to be successfully processed.
+
+All the problems found in the file are reported in one pass, with their line
+and column, as "error" (the file can't be processed) or "warning" (the file
+can be processed but contains suspicious data).
+
+The report can be generated as plain text (default), JSON or JUnit XML with
+the "--format" flag.
+
+With the "--fix" flag, the common defects of the file (blank values, whitespace,
+duplicate users, months out of order or missing) are repaired and the result is
+written to the file specified with "--out". Every change is listed in the report.
+With "--out=-", the repaired file is written to the standard output and the
+report to the standard error.
+
+When an aliases file is specified ("--aliases"), a warning is reported for every
+alias whose canonical login also has a row in the table.
+
+The file can be compressed with gzip or zstd. Use "-" to read the standard input.
+
+The command exits with 0 if no issue was found, 2 if only warnings were found
+and 1 if errors were found. With the "--strict" flag, the warnings are treated as
+errors (the command exits with 1).`,
 	Args: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfiguration(cmd); err != nil {
+			return err
+		}
 		if err := cobra.MinimumNArgs(1)(cmd, args); err != nil {
 			return err
 		}
-		if !isFileValid(args[0]) {
+		if !isInputFileValid(args[0]) {
 			return fmt.Errorf("Invalid file")
 		}
+		switch strings.ToLower(checkFormat) {
+		case "text", "json", "junit":
+		default:
+			return fmt.Errorf("%s is an invalid report format", checkFormat)
+		}
+		if isFixCheck && fixedOutputFileName == "" {
+			return fmt.Errorf("The repaired file name must be specified with --out")
+		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
+		var table *pivot.PivotTable
+		var report *pivot.Report
+		if isFixCheck {
+			table, report = repairFileReport(args[0])
+		} else {
+			table, report = checkFileReport(args[0])
+		}
+
+		standardOutput = cmd.OutOrStdout()
+		out := cmd.OutOrStdout()
+		if isFixCheck && fixedOutputFileName == standardOutputName {
+			// The standard output is used by the repaired pivot table
+			out = cmd.ErrOrStderr()
+		}
+		var err error
+		switch strings.ToLower(checkFormat) {
+		case "json":
+			err = writeJSONCheckReport(out, report)
+		case "junit":
+			err = writeJUnitCheckReport(out, report)
+		default:
+			if isVerboseCheck && table != nil {
+				writeTableSummary(out, table)
+			}
+			writeTextCheckReport(out, report)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if isFixCheck && !report.HasErrors() {
+			if err := writePivotTable(fixedOutputFileName, table); err != nil {
+				log.Fatal(err)
+			}
+			if strings.ToLower(checkFormat) == "text" {
+				fmt.Fprintf(out, "Repaired pivot table written to %s\n", describeOutputFile(fixedOutputFileName))
+			}
+		}
 
-		// When called standalone, we want to give at least some information
-		isSilent := false
-		if !checkFile(args[0], isSilent) {
-			fmt.Print("Check failed.")
-			os.Exit(1)
+		if exitCode := checkExitCode(report, isStrictCheck); exitCode != 0 {
+			os.Exit(exitCode)
 		}
 	},
 }
@@ -64,129 +147,200 @@ order to be successfully processed.`,
 // initialize the Cobra processor and flags
 func init() {
 	checkCmd.PersistentFlags().BoolVarP(&isVerboseCheck, "verbose", "v", false, "Displays useful info during the validation")
+	checkCmd.PersistentFlags().StringVarP(&checkFormat, "format", "f", "text", "Format of the validation report. Can be \"text\", \"json\" or \"junit\"")
+	checkCmd.PersistentFlags().BoolVarP(&isFixCheck, "fix", "", false, "Repairs the common defects of the file and writes the result to the \"--out\" file")
+	checkCmd.PersistentFlags().StringVarP(&fixedOutputFileName, "out", "o", "", "Output file name of the repaired pivot table (with \"--fix\")")
+	checkCmd.PersistentFlags().BoolVarP(&isStrictCheck, "strict", "", false, "Treats the warnings as errors (exits with 1)")
 
 	rootCmd.AddCommand(checkCmd)
 }
 
-// Loads the data from a file and try to parse it as a CSV
-func checkFile(fileName string, isSilent bool) bool {
-
-	var isValidTable = true
-	if isSilent {
-		isVerboseCheck = false
+// Returns the exit code of the check command for a report.
+// In strict mode, the warnings are treated as errors.
+func checkExitCode(report *pivot.Report, isStrict bool) int {
+	switch {
+	case report.HasErrors():
+		return checkExitErrors
+	case report.NbrOfWarnings() == 0:
+		return 0
+	case isStrict:
+		return checkExitErrors
+	default:
+		return checkExitWarnings
 	}
+}
 
-	f, err := os.Open(fileName)
-	if err != nil {
-		log.Printf("Unable to read input file "+fileName+"\n", err)
-		return false
+// Loads the data from a file and try to parse it as a pivot table
+func checkFile(fileName string, isSilent bool) bool {
+	_, report := checkFileReport(fileName)
+
+	if !isSilent {
+		writeTextCheckReport(os.Stdout, report)
 	}
-	defer f.Close()
 
-	r := csv.NewReader(f)
+	return !report.HasErrors()
+}
 
-	//The first record is not properly formatted, we skip it
-	firstLine, err1 := r.Read()
-	if err1 != nil {
-		log.Printf("Unexpected error loading"+fileName+"\n", err)
-		return false
+// Validates the file, collecting all the issues found.
+// The table is nil if the file can't be loaded.
+func checkFileReport(fileName string) (*pivot.PivotTable, *pivot.Report) {
+	table, report := pivot.CheckFile(fileName)
+	if table != nil {
+		checkTableContent(table, report)
+		table.CheckAliases(aliases, report)
 	}
+	return table, report
+}
 
-	if isVerboseCheck {
-		fmt.Println("Checking file format")
-		fmt.Printf("  - Number of columns defined in header: %d\n", len(firstLine))
+// Repairs the file, collecting the changes done and the remaining issues.
+// The table is nil if the file can't be repaired.
+func repairFileReport(fileName string) (*pivot.PivotTable, *pivot.Report) {
+	table, report := pivot.RepairFile(fileName)
+	if table != nil {
+		checkTableContent(table, report)
+		table.CheckAliases(aliases, report)
 	}
+	return table, report
+}
 
-	// first column should be empty
-	if firstLine[0] != "" {
-		fmt.Println("Not the expected first column name (should be empty)")
-		return false
+// Checks that a (structurally valid) pivot table contains enough data to be processed
+func checkTableContent(table *pivot.PivotTable, report *pivot.Report) {
+	if table.NbrOfMonths() < 2 {
+		report.Add(1, 0, pivot.SeverityError, ruleNotEnoughData, "Not enough monthly data available")
 	}
-	if isVerboseCheck {
-		fmt.Println("  - File's header start with empty column name.")
+	if table.NbrOfUsers() < 1 {
+		report.Add(0, 0, pivot.SeverityError, ruleNotEnoughData, "No data available after the header")
 	}
+}
 
-	//loop through columns to check headings
-	month_regexp, _ := regexp.Compile("20[0-9]{2}-[0-9]{2}")
-	for i, s := range firstLine {
-		if i != 0 {
-			if !month_regexp.MatchString(s) {
-				fmt.Printf("Column header %s is not of the expected format (YYYY-MM)\n", s)
-				return false
-			}
-		}
-	}
-	if isVerboseCheck {
-		endMonth := firstLine[len(firstLine)-1]
-		fmt.Printf("  - File's header data column format (\"20YY-MM\"). Most recent data is \"%s\"\n", endMonth)
+// Returns an error if a (structurally valid) pivot table can't be processed
+func checkTable(table *pivot.PivotTable) error {
+	report := &pivot.Report{Source: table.Source}
+	checkTableContent(table, report)
+	return report.FirstError()
+}
+
+// Writes some information about the loaded table
+func writeTableSummary(out io.Writer, table *pivot.PivotTable) {
+	fmt.Fprintf(out, "Checking file format\n")
+	fmt.Fprintf(out, "  - Number of columns defined in header: %d\n", table.NbrOfMonths()+1)
+	if table.NbrOfMonths() > 0 {
+		fmt.Fprintf(out, "  - Most recent data is \"%s\"\n", pivot.FormatMonth(table.Month(table.NbrOfMonths()-1)))
 	}
+	fmt.Fprintf(out, "  - Number of data records: %d\n\n", table.NbrOfUsers())
+}
 
-	nbrOfColumns := len(firstLine)
-	if nbrOfColumns < 3 {
-		fmt.Printf("Not enough monthly data available\n")
-		return false
+// Writes the validation report as human readable text
+func writeTextCheckReport(out io.Writer, report *pivot.Report) {
+	for _, fix := range report.Fixes {
+		fmt.Fprintf(out, "%-7s %s\n", "FIXED", fix.String())
 	}
-	if isVerboseCheck {
-		fmt.Printf("  - More than one month data available\n")
+	for _, issue := range report.Issues {
+		fmt.Fprintf(out, "%-7s %s\n", strings.ToUpper(issue.Severity.String()), issue.Error())
 	}
 
-	records, err := r.ReadAll()
-	if err != nil {
-		log.Printf("Unexpected error loading"+fileName+"\n", err)
-		return false
+	if report.HasErrors() {
+		fmt.Fprintf(out, "\nCheck of \"%s\" failed: %d error(s) and %d warning(s)\n", report.Source, report.NbrOfErrors(), report.NbrOfWarnings())
+		return
+	}
+	if report.NbrOfWarnings() > 0 {
+		fmt.Fprintf(out, "\n%d warning(s) found.\n", report.NbrOfWarnings())
 	}
+	fmt.Fprintf(out, "\nSuccessfully checked \"%s\"\n   It is a valid Jenkins Submitter Pivot Table and can be processes\n\n", report.Source)
+}
 
-	if len(records) < 2 {
-		fmt.Printf("No data available after the header\n")
-		return false
+// JSON representation of the validation report
+type jsonCheckReport struct {
+	Source   string        `json:"source"`
+	Valid    bool          `json:"valid"`
+	Errors   int           `json:"errors"`
+	Warnings int           `json:"warnings"`
+	Issues   []pivot.Issue `json:"issues"`
+	Fixes    []pivot.Fix   `json:"fixes,omitempty"`
+}
+
+// Writes the validation report as a JSON document
+func writeJSONCheckReport(out io.Writer, report *pivot.Report) error {
+	document := jsonCheckReport{
+		Source:   report.Source,
+		Valid:    !report.HasErrors(),
+		Errors:   report.NbrOfErrors(),
+		Warnings: report.NbrOfWarnings(),
+		Issues:   report.Issues,
+		Fixes:    report.Fixes,
 	}
-	if isVerboseCheck {
-		fmt.Println("  - At least one submitter's data available")
+	if document.Issues == nil {
+		document.Issues = []pivot.Issue{}
 	}
 
-	//The GitHub user validation regexp (see https://stackoverflow.com/questions/58726546/github-username-convention-using-regex)
-	// should be regexp.Compile(`^[a-zA-Z0-9]+(?:-[a-zA-Z0-9]+)*$`). But the dataset contains "invalid" data: username ending with a "-" or
-	// a double "-" in the name.
-	name_exp, _ := regexp.Compile(`^[a-zA-Z0-9\-]+$`)
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
 
-	//Check the loaded data
-	for i, dataLine := range records {
-		//Skip header line as it has already been checked
-		if i == 0 {
-			continue
+// JUnit XML representation of the validation report
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Writes the validation report as JUnit XML: every issue is a test case.
+// Errors are reported as failures, warnings and fixes as passed test cases with an output.
+func writeJUnitCheckReport(out io.Writer, report *pivot.Report) error {
+	suite := junitTestSuite{Name: report.Source}
+
+	for _, fix := range report.Fixes {
+		suite.Cases = append(suite.Cases, junitTestCase{Name: fix.String(), ClassName: "check.fix", SystemOut: "fixed: " + fix.String()})
+	}
+
+	for _, issue := range report.Issues {
+		testCase := junitTestCase{
+			Name:      issue.Error(),
+			ClassName: "check." + issue.Rule,
 		}
-		for ii, column := range dataLine {
-			//check the GitHub user (first columns)
-			if ii == 0 {
-				if !(len(column) < 40 && len(column) > 0 && name_exp.MatchString(column)) {
-					if column != "deleted_user" {
-						fmt.Printf("User \"%s\" at line %d does not follow GitHub rules\n", column, i)
-						return false
-					}
-				}
-			} else {
-				// check the other columns is an integer (we don't check the sign)
-				if data_value, err := strconv.Atoi(column); err != nil {
-					fmt.Printf("Value \"%s\" at line %d (column %d) isn't an integer\n", column, i, ii)
-					return false
-				} else {
-					if data_value < 0 {
-						fmt.Printf("Value \"%s\" at line %d (column %d) is negative\n", column, i, ii)
-						return false
-					}
-				}
-			}
+		if issue.Severity == pivot.SeverityError {
+			testCase.Failure = &junitFailure{Message: issue.Message, Type: issue.Severity.String(), Text: issue.Error()}
+			suite.Failures++
+		} else {
+			testCase.SystemOut = issue.Severity.String() + ": " + issue.Error()
 		}
+		suite.Cases = append(suite.Cases, testCase)
 	}
 
-	if isVerboseCheck {
-		fmt.Println("  - Number of data columns match header columns.")
-		fmt.Printf("  - Records have a valid GitHub username and number of submitted PRs. (%d data records)\n", len(records)-1)
+	// A valid file is reported as one successful test case
+	if len(suite.Cases) == 0 {
+		suite.Cases = append(suite.Cases, junitTestCase{Name: "pivot table format", ClassName: "check"})
 	}
+	suite.Tests = len(suite.Cases)
 
-	if !isSilent {
-		fmt.Printf("\nSuccessfully checked \"%s\"\n   It is a valid Jenkins Submitter Pivot Table and can be processes\n\n", fileName)
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
 	}
-
-	return isValidTable
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
 }
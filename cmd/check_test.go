@@ -21,7 +21,16 @@ THE SOFTWARE.
 */
 package cmd
 
-import "testing"
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+	"github.com/stretchr/testify/assert"
+)
 
 func Test_checkFile(t *testing.T) {
 	type args struct {
@@ -106,3 +115,134 @@ func Test_checkFile(t *testing.T) {
 		})
 	}
 }
+
+func Test_checkFileReport(t *testing.T) {
+	table, report := checkFileReport("../test_data/bad_data_negative_value.csv")
+	assert.Nil(t, table, "No table expected for an invalid file")
+	assert.True(t, report.HasErrors())
+	assert.Equal(t, "../test_data/bad_data_negative_value.csv", report.Source)
+
+	table, report = checkFileReport("../test_data/noData_overview.csv")
+	assert.NotNil(t, table, "Table is structurally valid")
+	assert.Equal(t, 1, report.NbrOfErrors(), "Missing data should be reported")
+	assert.Equal(t, ruleNotEnoughData, report.Issues[0].Rule)
+}
+
+func Test_checkExitCode(t *testing.T) {
+	warnings := &pivot.Report{}
+	warnings.Add(4, 1, pivot.SeverityWarning, pivot.RuleUserConvention, "User \"tobias-\" does not follow the strict GitHub naming convention")
+	errors := &pivot.Report{}
+	errors.Add(3, 2, pivot.SeverityError, pivot.RuleValue, "Value \"x\" isn't an integer")
+
+	tests := []struct {
+		name     string
+		report   *pivot.Report
+		isStrict bool
+		want     int
+	}{
+		{"no issue", &pivot.Report{}, false, 0},
+		{"no issue in strict mode", &pivot.Report{}, true, 0},
+		{"warnings only", warnings, false, checkExitWarnings},
+		{"warnings only in strict mode", warnings, true, checkExitErrors},
+		{"errors", errors, false, checkExitErrors},
+		{"errors in strict mode", errors, true, checkExitErrors},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, checkExitCode(tt.report, tt.isStrict))
+		})
+	}
+}
+
+func Test_writeTextCheckReport(t *testing.T) {
+	report := &pivot.Report{Source: "test.csv"}
+	report.Add(3, 2, pivot.SeverityError, pivot.RuleValue, "Value \"x\" isn't an integer")
+	report.Add(4, 1, pivot.SeverityWarning, pivot.RuleUserConvention, "User \"tobias-\" does not follow the strict GitHub naming convention")
+
+	actual := new(bytes.Buffer)
+	writeTextCheckReport(actual, report)
+
+	expected := "ERROR   line 3, column 2: Value \"x\" isn't an integer\n" +
+		"WARNING line 4, column 1: User \"tobias-\" does not follow the strict GitHub naming convention\n" +
+		"\nCheck of \"test.csv\" failed: 1 error(s) and 1 warning(s)\n"
+	assert.Equal(t, expected, actual.String())
+}
+
+func Test_writeJSONCheckReport(t *testing.T) {
+	_, report := checkFileReport("../test_data/bad_data_value.csv")
+
+	actual := new(bytes.Buffer)
+	assert.NoError(t, writeJSONCheckReport(actual, report))
+
+	var document jsonCheckReport
+	assert.NoError(t, json.Unmarshal(actual.Bytes(), &document), "Output is not valid JSON")
+	assert.False(t, document.Valid)
+	assert.Equal(t, report.NbrOfErrors(), document.Errors)
+	assert.Contains(t, actual.String(), `"severity": "error"`)
+	assert.Contains(t, actual.String(), `"rule": "value"`)
+}
+
+func Test_writeJUnitCheckReport(t *testing.T) {
+	report := &pivot.Report{Source: "test.csv"}
+	report.Add(3, 2, pivot.SeverityError, pivot.RuleValue, "Value \"x\" isn't an integer")
+	report.Add(4, 1, pivot.SeverityWarning, pivot.RuleUserConvention, "User \"tobias-\" does not follow the strict GitHub naming convention")
+
+	actual := new(bytes.Buffer)
+	assert.NoError(t, writeJUnitCheckReport(actual, report))
+
+	var suites junitTestSuites
+	assert.NoError(t, xml.Unmarshal(actual.Bytes(), &suites), "Output is not valid XML")
+	assert.Len(t, suites.Suites, 1)
+	assert.Equal(t, 2, suites.Suites[0].Tests)
+	assert.Equal(t, 1, suites.Suites[0].Failures)
+
+	// A valid file generates a single passing test case
+	actual.Reset()
+	assert.NoError(t, writeJUnitCheckReport(actual, &pivot.Report{Source: "valid.csv"}))
+	var validSuites junitTestSuites
+	assert.NoError(t, xml.Unmarshal(actual.Bytes(), &validSuites))
+	assert.Equal(t, 1, validSuites.Suites[0].Tests)
+	assert.Equal(t, 0, validSuites.Suites[0].Failures)
+}
+
+func Test_ExecuteCheckWithFix_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := tempDir + "/repaired.csv"
+	goldenFilename, err := duplicateFile("../test_data/repaired_overview_reference.csv", tempDir)
+
+	assert.NoError(t, err, "Unexpected Golden File duplication error")
+	assert.NotEmpty(t, goldenFilename, "Failure to duplicate Golden File")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"check", "../test_data/malformed_overview.csv", "--fix", "--out=" + testOutputFilename})
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.NoError(t, isFileEquivalent(testOutputFilename, goldenFilename))
+	assert.Contains(t, actual.String(), "FIXED   line 4, column 1: Summed duplicate user \"basil\" into line 2")
+}
+
+func Test_ExecuteCheckWithFixNoOutput_mustFail(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"check", "../test_data/malformed_overview.csv", "--fix", "--out="})
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	assert.Error(t, error, "Function call should have failed")
+
+	//Error is expected
+	expectedMsg := "Error: The repaired file name must be specified with --out"
+	lines := strings.Split(actual.String(), "\n")
+	assert.Equal(t, expectedMsg, lines[0], "Function did not fail for the expected cause")
+}
@@ -0,0 +1,155 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+)
+
+// Validates the flags of a combined (submitters and commenters) extraction
+func checkCombinedArgs(inputFileName string) error {
+	if !isInputFileValid(commentersFileName) {
+		return fmt.Errorf("Invalid commenters input file\n")
+	}
+	if commentersFileName == pivot.StdinName && inputFileName == pivot.StdinName {
+		return fmt.Errorf("The standard input (\"-\") can only be used once\n")
+	}
+	if inputType != InputTypeSubmitters {
+		return fmt.Errorf("The input file must be the submitters pivot table when \"--commenters\" is specified\n")
+	}
+	if submittersWeight < 0 || commentersWeight < 0 {
+		return fmt.Errorf("The weights can't be negative\n")
+	}
+	if isOutputHistory {
+		return fmt.Errorf("The history is not available for a combined extraction\n")
+	}
+	if isRankingOutput {
+		return fmt.Errorf("The ranking columns are not available for a combined extraction\n")
+	}
+	if isGroupedByAffiliation() {
+		return fmt.Errorf("The grouping by affiliation is not available for a combined extraction\n")
+	}
+	if len(repoPatterns) > 0 || len(labelPatterns) > 0 {
+		return fmt.Errorf("The repository and label filters are not available for a combined extraction\n")
+	}
+	return nil
+}
+
+// Extracts the top contributors on the combined score of the two tables and writes them to a file
+func runCombinedExtract(submittersFileName string, commentersFileName string) error {
+	submitters, err := loadInputPivotTable(submittersFileName)
+	if err != nil {
+		return err
+	}
+	commenters, err := loadInputPivotTable(commentersFileName)
+	if err != nil {
+		return fmt.Errorf("%s: %v", commentersFileName, err)
+	}
+
+	result, real_endDate, scores := extractCombinedData(submitters, commenters, submittersWeight, commentersWeight, topSize, endMonth, period, isVerboseExtract)
+	if !result {
+		return fmt.Errorf("Failed to extract data")
+	}
+	csv_output_slice := combinedRecords(scores)
+
+	// If the default value is specified, update that default with the month being used for the calculation
+	if outputFileName == "top-submitters_YYYY-MM.csv" {
+		outputFileName = defaultOutputFileName("top-contributors_", endMonth, argOutputFormat)
+	}
+	outputFormat := getOutputFormat(outputFileName, argOutputFormat)
+
+	if isVerboseExtract {
+		fmt.Fprintf(os.Stderr, "Writing extraction to %s %s\n\n", describeOutputFile(outputFileName), describeOutputFormat(outputFormat))
+	}
+
+	// Check that the output directory exists
+	if err := CheckDir(outputFileName); err != nil {
+		return err
+	}
+
+	switch outputFormat {
+	case outputFormatMarkdown:
+		introduction := "# Top Contributors\n"
+		introduction = introduction + fmt.Sprintf("\nExtraction of the %d top contributors (non-bot) \nover the %d months before \"%s\".\n", topSize, period, real_endDate)
+		introduction = introduction + fmt.Sprintf("The score is the number of PRs x %s plus the number of comments x %s.\n", formatScore(submittersWeight), formatScore(commentersWeight))
+		introduction = introduction + excludedAccountsNote() + "\n"
+		writeDataAsMarkdown(outputFileName, csv_output_slice, introduction, false, InputTypeSubmitters)
+	case outputFormatJSON:
+		document := newCombinedJSONOutput(submitters, commenters, real_endDate, scores)
+		if err := writeJSONOutput(outputFileName, document); err != nil {
+			return err
+		}
+	default:
+		writeCSVtoFile(outputFileName, csv_output_slice)
+		writeExcludedAccountsCSV(outputFileName)
+	}
+	return nil
+}
+
+// Ranks the users of both tables on the weighted sum of their PRs and comments for a given period.
+// The period is computed on the submitters table and applied, in calendar months, to the commenters table.
+func extractCombinedData(submitters *pivot.PivotTable, commenters *pivot.PivotTable, submittersWeight float64, commentersWeight float64, topSize int, endMonth string, period int, isVerboseExtract bool) (result bool, real_endDate string, scores []pivot.Score) {
+	if isVerboseExtract {
+		fmt.Fprintf(os.Stderr, "Extracting from \"%s\" and \"%s\" the %d top contributors during the last %d months\n\n", submitters.Source, commenters.Source, topSize, period)
+	}
+
+	_, _, oldestDate, mostRecentDate, err := getBoundaries(submitters, endMonth, period, 0)
+	if err != nil {
+		log.Printf("%v\n", err)
+		return false, "", nil
+	}
+	fromMonth, _ := pivot.ParseMonth(oldestDate)
+	toMonth, _ := pivot.ParseMonth(mostRecentDate)
+
+	fmt.Fprintf(os.Stderr, "Accumulating data between %s and  %s\n", oldestDate, mostRecentDate)
+
+	scores = pivot.TopScores(pivot.CombinedScores(fromMonth, toMonth,
+		pivot.Weighted{Table: submitters, Weight: submittersWeight},
+		pivot.Weighted{Table: commenters, Weight: commentersWeight}), topSize)
+
+	return true, mostRecentDate, scores
+}
+
+// Returns the CSV records of the combined scores (header included)
+func combinedRecords(scores []pivot.Score) [][]string {
+	records := [][]string{{"Contributor", "Score", InputTypeSubmitters.TotalColumn, InputTypeCommenters.TotalColumn}}
+	for _, score := range scores {
+		records = append(records, []string{score.User, formatScore(score.Score), strconv.Itoa(score.Totals[0]), strconv.Itoa(score.Totals[1])})
+	}
+	return records
+}
+
+// Formats a score or a weight with at most two decimals
+func formatScore(score float64) string {
+	return strconv.FormatFloat(roundScore(score), 'f', -1, 64)
+}
+
+// Rounds a score to two decimals
+func roundScore(score float64) float64 {
+	return math.Round(score*100) / 100
+}
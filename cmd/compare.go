@@ -24,128 +24,116 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"strings"
+	"strconv"
 
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
 	"github.com/spf13/cobra"
 )
 
 var compareWith int
+var isRankMovement bool
+var isDetailedStatus bool
+
+// Status of the users in the COMPARE output
+const (
+	statusNew     = "new"
+	statusChurned = "churned"
+	// Detailed statuses (unless "--detailedStatus=false")
+	statusDropped   = "dropped"
+	statusInactive  = "inactive"
+	statusReturning = "returning"
+)
 
 // compareCmd represents the compare command
 var compareCmd = &cobra.Command{
 	Use:   "compare",
 	Short: "Compares two top Submitters extractions to show \"churned\" or \"new\" submitters.",
 	Long: `The COMPARE command will will extract a the Top Submitters as with the EXTRACT command and than
-compare it with an extraction with the same settings but with an X amount of months before`,
+compare it with an extraction with the same settings but with an X amount of months before.
+
+With the "--movement" flag, the previous and current ranks of the users, the change
+of rank (▲ when rising, ▼ when declining), their previous total and the change of
+their total (in absolute value and in percent) are added to the output. For the
+"churned" users, the change is computed with their total of the current period.
+
+The status is derived from the full pivot table:
+  - a "churned" user is either "dropped" (still active, but below the top) or
+    "inactive" (no activity during the current period),
+  - a "new" user is "returning" if they were inactive during the compared period
+    but active before it.
+With "--detailedStatus=false", only the "new" and "churned" statuses of the two
+extractions are reported, as in the previous versions.
+
+With "--group-by=affiliation", the organizations of the users (as mapped in the
+"--affiliations" file) are compared instead of the users.
+
+As with the EXTRACT command, the contributions of a long-format input can be filtered
+with "--repo" and "--label" (globs, "!" to exclude).`,
 	Args: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfiguration(cmd); err != nil {
+			return err
+		}
 		if err := cobra.MinimumNArgs(1)(cmd, args); err != nil {
 			return err
 		}
-		if !isFileValid(args[0]) {
+		if !isInputFileValid(args[0]) {
 			return fmt.Errorf("Invalid input file\n")
 		}
 		if !isValidMonth(endMonth, isVerboseExtract) {
 			return fmt.Errorf("\"%s\" is an invalid month\n", endMonth)
 		}
 
-		// check the input type
-		switch strings.ToLower(argInputType) {
-		case "submitters":
-			inputType = InputTypeSubmitters
-		case "commenters":
-			inputType = InputTypeCommenters
-		default:
-			inputType = InputTypeUnknown
+		if !isValidOutputFormat(argOutputFormat) {
+			return fmt.Errorf("%s is an invalid output format\n", argOutputFormat)
 		}
 
+		// check the input type
+		inputType = lookupInputType(argInputType)
+
 		if inputType == InputTypeUnknown {
 			return fmt.Errorf("%s is an invalid input type\n", argInputType)
 		}
+		if err := checkGroupByArgs(); err != nil {
+			return err
+		}
+		if err := checkContributionFilterArgs(); err != nil {
+			return err
+		}
+		if err := checkHistoryArgs(); err != nil {
+			return err
+		}
 
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// When called standalone, we want to give the minimal information
-		isSilent := true
-
 		inputPivotTableName := args[0]
+		standardOutput = cmd.OutOrStdout()
 
-		if !checkFile(inputPivotTableName, isSilent) {
-			fmt.Print("Invalid input file.")
-			os.Exit(1)
-		}
-
-		if outputFileName == "top-submitters_YYYY-MM.csv" {
-			outputFileName = "top-submitters_" + strings.ToUpper(endMonth) + ".csv"
-		}
-
-		// Extract the data (with no offset)
-		result, real_endDate, csv_output_slice := extractData(args[0], topSize, endMonth, period, 0, inputType, isVerboseExtract)
-		if !result {
-			return fmt.Errorf("Failed to extract data")
+		// Load and check input file
+		table, err := loadInputPivotTable(inputPivotTableName)
+		if err != nil {
+			return err
 		}
 
-		// Extract the data (with offset this time)
-		result, _, csv_offset_output_slice := extractData(args[0], topSize, endMonth, period, compareWith, inputType, isVerboseExtract)
-		if !result {
-			return fmt.Errorf("Failed to extract offset-ted data")
+		kind := inputType
+		if isGroupedByAffiliation() {
+			if table, kind, err = groupByAffiliations(table, inputType); err != nil {
+				return err
+			}
 		}
 
-		enrichedExtractedData := compareExtractedData(csv_output_slice, csv_offset_output_slice, inputType)
-
-		//FIXME: this seems duplicate with line 76
-
 		//FIXME: change default filename when specifying another type of input
 		// If the default value is specified, update that default with the month being used for the calculation
 		if outputFileName == "top-submitters_YYYY-MM.csv" {
-			outputFileName = "top-submitters_" + strings.ToUpper(endMonth) + ".csv"
-		}
-		isMDoutput := isWithMDfileExtension(outputFileName)
-
-		if isVerboseExtract {
-			fileTypeText := "(CSV format)"
-			if isMDoutput {
-				fileTypeText = "(Markdown format)"
+			prefix := "top-submitters_"
+			if isGroupedByAffiliation() {
+				prefix = "top-organizations_"
 			}
-			fmt.Printf("Writing compare results to \"%s\" %s\n\n", outputFileName, fileTypeText)
-		}
-
-		// Check that the output directory exists
-		dirErr := CheckDir(outputFileName)
-		if dirErr != nil {
-			return dirErr
+			outputFileName = defaultOutputFileName(prefix, endMonth, argOutputFormat)
 		}
 
-		if isMDoutput {
-			introduction := ""
-			if inputType == InputTypeSubmitters {
-				introduction = "# Top Submitters (Compare)\n"
-				buffer := fmt.Sprintf("\nExtraction of the %d top submitters (non-bot PR creators) \nover the %d months before \"%s\".\n", topSize, period, real_endDate)
-				buffer = buffer + fmt.Sprintf("Table shows new and \"churned\" submitters compared \nto the situation %d months before.\n\n", compareWith)
-				introduction = introduction + buffer
-			}
-			if inputType == InputTypeCommenters {
-				introduction = "# Top Commenters (Compare)\n"
-				buffer := fmt.Sprintf("\nExtraction of the %d top (non-bot) commenters \nover the %d months before \"%s\".\n", topSize, period, real_endDate)
-				buffer = buffer + fmt.Sprintf("Table shows new and \"churned\" commenters compared \nto the situation %d months before.\n\n", compareWith)
-				introduction = introduction + buffer
-			}
-			writeDataAsMarkdown(outputFileName, enrichedExtractedData, introduction, isOutputHistory, inputType)
-		} else {
-			writeCSVtoFile(outputFileName, enrichedExtractedData)
-		}
-
-		//if requested, write the history based the supplied top user slice
-		if isOutputHistory {
-			isCompare := true
-			historyOutputFilename := generateHistoryFilename(outputFileName, inputType, isCompare)
-
-			if err := writeHistoryOutput(historyOutputFilename, inputPivotTableName, inputType, enrichedExtractedData); err != nil {
-				return err
-			}
-		}
-
-		return nil
+		_, err = writeComparison(table, kind, outputFileName, isOutputHistory)
+		return err
 	},
 }
 
@@ -153,28 +141,117 @@ func init() {
 	rootCmd.AddCommand(compareCmd)
 
 	// Here you will define your flags and configuration settings.
-	compareCmd.PersistentFlags().StringVarP(&outputFileName, "out", "o", "top-submitters_YYYY-MM.csv", "Output file name.")
-	compareCmd.PersistentFlags().StringVarP(&argInputType, "type", "", "submitters", "The type of data being analyzed. Can be either \"submitters\" or \"commenters\"")
+	compareCmd.PersistentFlags().StringVarP(&outputFileName, "out", "o", "top-submitters_YYYY-MM.csv", "Output file name (\"-\" for the standard output).")
+	compareCmd.PersistentFlags().StringVarP(&argOutputFormat, "format", "f", "auto", "Output format. Can be \"auto\" (based on the file extension), \"csv\", \"md\" or \"json\"")
+	compareCmd.PersistentFlags().StringVarP(&argInputType, "type", "", "submitters", "The type of data being analyzed. Can be \"submitters\", \"commenters\" or any kind defined with \"--kinds\"")
 	compareCmd.PersistentFlags().IntVarP(&topSize, "topSize", "t", 35, "Number of top submitters to extract.")
 	compareCmd.PersistentFlags().IntVarP(&period, "period", "p", 12, "Number of months to accumulate.")
 	compareCmd.PersistentFlags().IntVarP(&compareWith, "compare", "c", 3, "Number of months back to compare with.")
 	compareCmd.PersistentFlags().StringVarP(&endMonth, "month", "m", "latest", "Month to extract top submitters.")
 	compareCmd.PersistentFlags().BoolVarP(&isOutputHistory, "history", "", false, "Outputs the available activity history for the top submitters")
+	compareCmd.PersistentFlags().BoolVarP(&isRankMovement, "movement", "", false, "Adds the previous and current ranks and the change of the totals of the users")
+	compareCmd.PersistentFlags().BoolVarP(&isDetailedStatus, "detailedStatus", "", true, "Distinguishes the \"dropped\", \"inactive\" and \"returning\" users (\"false\" for the \"new\" and \"churned\" statuses only)")
+
+	compareCmd.PersistentFlags().StringVarP(&groupBy, "group-by", "", groupByUser, "Ranks the users (\"user\") or their organizations (\"affiliation\", requires \"--affiliations\")")
+	compareCmd.PersistentFlags().StringVarP(&affiliationsFileName, "affiliations", "", "", "YAML or CSV file mapping the users to their organizations (with \"--group-by=affiliation\")")
+
+	compareCmd.PersistentFlags().StringSliceVarP(&repoPatterns, "repo", "", nil, "Repositories to count the contributions of, as globs (\"jenkinsci/*-plugin\", \"!\" to exclude). Requires a long-format input")
+	compareCmd.PersistentFlags().StringSliceVarP(&labelPatterns, "label", "", nil, "Labels to count the contributions of, as globs (\"!dependencies\" to exclude). Requires a long-format input")
 
 	compareCmd.PersistentFlags().BoolVarP(&isVerboseExtract, "verbose", "v", false, "Displays useful info during the extraction")
 }
 
-func compareExtractedData(recentData [][]string, oldData [][]string, inputType InputType) (enrichedExtractedData [][]string) {
-	var output_slice [][]string
-	var header_row []string
+// Extracts the top users of the table, compares them with the ones of "compareWith" months before
+// and writes the result to a CSV, Markdown or JSON file (see getOutputFormat).
+// If requested, the history of the top users and their plots are written in the same directory.
+// Returns the end month of the extraction.
+func writeComparison(table *pivot.PivotTable, inputType InputType, outputFileName string, isHistory bool) (string, error) {
+	// Extract the data (with no offset)
+	recent, err := extractTop(table, topSize, endMonth, period, 0, inputType, isVerboseExtract)
+	if err != nil {
+		return "", fmt.Errorf("Failed to extract data")
+	}
+	real_endDate := recent.endMonth
+
+	// Extract the data (with offset this time)
+	old, err := extractTop(table, topSize, endMonth, period, compareWith, inputType, isVerboseExtract)
+	if err != nil {
+		return "", fmt.Errorf("Failed to extract offset-ted data")
+	}
 
-	if inputType == InputTypeSubmitters {
-		header_row = []string{"Submitter", "Total_PRs", "Status"}
+	compared, err := compareExtractions(table, recent, old)
+	if err != nil {
+		return "", err
 	}
-	if inputType == InputTypeCommenters {
-		//FIXME: Check inconsistant capitalisation of Status
-		header_row = []string{"Commenter", "Comments", "status"}
+	if isDetailedStatus {
+		if err := refineCompareStatus(table, compared); err != nil {
+			return "", err
+		}
 	}
+	if isRankMovement {
+		if err := addMovement(table, compared, old); err != nil {
+			return "", err
+		}
+	}
+	enrichedExtractedData := compared.records()
+
+	outputFormat := getOutputFormat(outputFileName, argOutputFormat)
+
+	// The JSON entries embed the breakdown per repository, the other formats get an additional column
+	output_slice := enrichedExtractedData
+	if outputFormat != outputFormatJSON {
+		breakdown, err := repoBreakdown(table, inputType, real_endDate)
+		if err != nil {
+			return "", err
+		}
+		if breakdown != nil {
+			output_slice = addRepoColumn(enrichedExtractedData, breakdown)
+		}
+	}
+
+	if isVerboseExtract {
+		fmt.Fprintf(os.Stderr, "Writing compare results to %s %s\n\n", describeOutputFile(outputFileName), describeOutputFormat(outputFormat))
+	}
+
+	// Check that the output directory exists
+	dirErr := CheckDir(outputFileName)
+	if dirErr != nil {
+		return "", dirErr
+	}
+
+	switch outputFormat {
+	case outputFormatMarkdown:
+		introduction := "# " + inputType.Title + " (Compare)\n"
+		introduction = introduction + "\n" + inputType.markdownIntroduction(topSize, period, real_endDate) + "\n"
+		introduction = introduction + fmt.Sprintf("Table shows new and \"churned\" %s compared \nto the situation %d months before.\n", inputType.rankedName(), compareWith)
+		introduction = introduction + contributionFiltersNote() + excludedAccountsNote() + "\n"
+		writeDataAsMarkdown(outputFileName, output_slice, introduction, isHistory, inputType)
+	case outputFormatJSON:
+		document := newJSONOutput(table, compared, compareWith, isHistory)
+		if err := writeJSONOutput(outputFileName, document); err != nil {
+			return "", err
+		}
+	default:
+		writeCSVtoFile(outputFileName, output_slice)
+		writeExcludedAccountsCSV(outputFileName)
+	}
+
+	//if requested, write the history based the supplied top user slice
+	if isHistory {
+		isCompare := true
+		historyOutputFilename := generateHistoryFilename(outputFileName, inputType, isCompare)
+
+		if err := writeHistoryOutput(historyOutputFilename, table, inputType, enrichedExtractedData); err != nil {
+			return "", err
+		}
+	}
+
+	return real_endDate, nil
+}
+
+func compareExtractedData(recentData [][]string, oldData [][]string, inputType InputType) (enrichedExtractedData [][]string) {
+	var output_slice [][]string
+	header_row := []string{inputType.Label, inputType.CompareTotalColumn, inputType.CompareStatusColumn}
 
 	output_slice = append(output_slice, header_row)
 
@@ -187,7 +264,7 @@ func compareExtractedData(recentData [][]string, oldData [][]string, inputType I
 
 		status := ""
 		if !isSubmitterFound(oldData, recentData[i][0]) {
-			status = "new"
+			status = statusNew
 		}
 
 		dataRow := []string{recentData[i][0], recentData[i][1], status}
@@ -202,13 +279,34 @@ func compareExtractedData(recentData [][]string, oldData [][]string, inputType I
 		}
 
 		if !isSubmitterFound(recentData, oldData[i][0]) {
-			dataRow := []string{oldData[i][0], "", "churned"}
+			dataRow := []string{oldData[i][0], "", statusChurned}
 			output_slice = append(output_slice, dataRow)
 		}
 	}
 	return output_slice
 }
 
+// Returns the total of every user over the period ending "offset" months before the end month
+func windowTotals(table *pivot.PivotTable, endMonth string, period int, offset int) (map[string]int, error) {
+	firstDataColumn, lastDataColumn, _, _, err := getBoundaries(table, endMonth, period, offset)
+	if err != nil {
+		return nil, err
+	}
+	totals := make(map[string]int)
+	for _, total := range table.Totals(firstDataColumn, lastDataColumn) {
+		totals[total.User] = total.Total
+	}
+	return totals, nil
+}
+
+// Formats a rank, empty when the user is not in the top
+func formatRank(rank int) string {
+	if rank == 0 {
+		return ""
+	}
+	return strconv.Itoa(rank)
+}
+
 // Check whether the submitter exists in the supplied dataset
 func isSubmitterFound(dataset [][]string, submitter string) (found bool) {
 	for i := range dataset {
@@ -27,6 +27,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -131,7 +132,8 @@ func Test_ExecuteCommentersCompareToMarkdown_integrationTest(t *testing.T) {
 	actual := new(bytes.Buffer)
 	rootCmd.SetOut(actual)
 	rootCmd.SetErr(actual)
-	rootCmd.SetArgs([]string{"compare", "../test_data/overview.csv", "--month=latest", "--period=12", "--topSize=35", "--compare=3", "--type=commenters", "--out=" + testOutputFilename})
+	rootCmd.SetArgs([]string{"compare", "../test_data/overview.csv", "--month=latest", "--period=12", "--topSize=35", "--compare=3", "--type=commenters", "--detailedStatus=false", "--out=" + testOutputFilename})
+	defer func() { isDetailedStatus = true }()
 
 	// Execute the module under test
 	error := rootCmd.Execute()
@@ -154,6 +156,54 @@ func Test_ExecuteSubmitterCompareToMarkdown_integrationTest(t *testing.T) {
 	actual := new(bytes.Buffer)
 	rootCmd.SetOut(actual)
 	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"compare", "../test_data/overview.csv", "--month=latest", "--period=12", "--topSize=35", "--compare=3", "--type=submitters", "--detailedStatus=false", "--out=" + testOutputFilename})
+	defer func() { isDetailedStatus = true }()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.NoError(t, isFileEquivalent(testOutputFilename, goldenMarkdownFilename))
+}
+
+func Test_ExecuteSubmitterCompareWithMovement_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := tempDir + "compare_movement_output.md"
+	goldenMarkdownFilename, err := duplicateFile("../test_data/compare-movement_reference_output.md", tempDir)
+
+	assert.NoError(t, err, "Unexpected Golden File duplication error")
+	assert.NotEmpty(t, goldenMarkdownFilename, "Failure to duplicate Golden File")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"compare", "../test_data/overview.csv", "--month=latest", "--period=12", "--topSize=12", "--compare=3", "--type=submitters", "--movement", "--detailedStatus=false", "--out=" + testOutputFilename})
+	defer func() { isRankMovement = false; isDetailedStatus = true; topSize = 35 }()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.NoError(t, isFileEquivalent(testOutputFilename, goldenMarkdownFilename))
+}
+
+func Test_ExecuteSubmitterCompareWithDetailedStatus_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := tempDir + "compare_detailed_output.md"
+	goldenMarkdownFilename, err := duplicateFile("../test_data/compare-detailed_reference_output.md", tempDir)
+
+	assert.NoError(t, err, "Unexpected Golden File duplication error")
+	assert.NotEmpty(t, goldenMarkdownFilename, "Failure to duplicate Golden File")
+
+	// setup the command line (the detailed status is the default)
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
 	rootCmd.SetArgs([]string{"compare", "../test_data/overview.csv", "--month=latest", "--period=12", "--topSize=35", "--compare=3", "--type=submitters", "--out=" + testOutputFilename})
 
 	// Execute the module under test
@@ -164,6 +214,67 @@ func Test_ExecuteSubmitterCompareToMarkdown_integrationTest(t *testing.T) {
 	assert.NoError(t, isFileEquivalent(testOutputFilename, goldenMarkdownFilename))
 }
 
+func Test_refineCompareStatus(t *testing.T) {
+	table := loadTestTable(t, [][]string{
+		{"", "2023-01", "2023-02", "2023-03", "2023-04", "2023-05", "2023-06"},
+		{"alpha", "3", "0", "0", "0", "5", "4"},
+		{"bravo", "0", "0", "6", "6", "1", "0"},
+		{"charly", "0", "0", "5", "5", "0", "0"},
+		{"delta", "0", "0", "1", "0", "6", "6"},
+	})
+	recent := newExtraction(InputTypeSubmitters, "2023-06", []pivot.Total{{User: "delta", Total: 12}, {User: "alpha", Total: 9}})
+	old := newExtraction(InputTypeSubmitters, "2023-04", []pivot.Total{{User: "bravo", Total: 12}, {User: "charly", Total: 10}})
+
+	savedPeriod, savedCompareWith := period, compareWith
+	period, compareWith = 2, 2
+	defer func() { period, compareWith = savedPeriod, savedCompareWith }()
+
+	compared, err := compareExtractions(table, recent, old)
+	assert.NoError(t, err)
+
+	err = refineCompareStatus(table, compared)
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"Submitter", "Total_PRs", "Status"},
+		{"delta", "12", "new"},
+		{"alpha", "9", "returning"},
+		{"bravo", "", "dropped"},
+		{"charly", "", "inactive"},
+	}, compared.records())
+}
+
+func Test_addMovement(t *testing.T) {
+	table := loadTestTable(t, [][]string{
+		{"", "2023-01", "2023-02", "2023-03"},
+		{"alpha", "4", "1", "1"},
+		{"bravo", "1", "3", "6"},
+		{"charly", "2", "2", "0"},
+		{"delta", "0", "0", "1"},
+	})
+	recent := newExtraction(InputTypeSubmitters, "2023-03", []pivot.Total{{User: "bravo", Total: 6}, {User: "alpha", Total: 1}, {User: "delta", Total: 1}})
+	old := newExtraction(InputTypeSubmitters, "2023-02", []pivot.Total{{User: "bravo", Total: 3}, {User: "charly", Total: 2}})
+
+	savedPeriod, savedCompareWith := period, compareWith
+	period, compareWith = 1, 1
+	defer func() { period, compareWith = savedPeriod, savedCompareWith }()
+
+	compared, err := compareExtractions(table, recent, old)
+	assert.NoError(t, err)
+
+	err = addMovement(table, compared, old)
+
+	assert.NoError(t, err)
+	got := compared.records()
+	assert.Equal(t, [][]string{
+		{"Submitter", "Total_PRs", "Status", "Previous_Rank", "Rank", "Rank_Change", "Previous_Total", "Change", "Change_%"},
+		{"bravo", "6", "", "1", "1", "=", "3", "+3", "+100.00"},
+		{"alpha", "1", "new", "", "2", "", "1", "+0", "+0.00"},
+		{"delta", "1", "new", "", "2", "", "0", "+1", ""},
+		{"charly", "", "churned", "2", "", "", "2", "-2", "-100.00"},
+	}, got)
+}
+
 func Test_ExecuteCompareWithUnknownInputType_mustFail(t *testing.T) {
 	// setup the command line
 	actual := new(bytes.Buffer)
@@ -272,7 +383,8 @@ func Test_ExecuteSubmitterCompareWithHistory_integrationTest(t *testing.T) {
 	actual := new(bytes.Buffer)
 	rootCmd.SetOut(actual)
 	rootCmd.SetErr(actual)
-	rootCmd.SetArgs([]string{"compare", "../test_data/overview.csv", "--history", "--month=latest", "--period=12", "--topSize=35", "--compare=3", "--type=submitters", "--out=" + testOutputFilename})
+	rootCmd.SetArgs([]string{"compare", "../test_data/overview.csv", "--history", "--month=latest", "--period=12", "--topSize=35", "--compare=3", "--type=submitters", "--detailedStatus=false", "--out=" + testOutputFilename})
+	defer func() { isDetailedStatus = true }()
 
 	// Execute the module under test
 	error := rootCmd.Execute()
@@ -0,0 +1,169 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// Names of the configuration files looked up in the home directory when "--config" isn't specified
+// (the first one found is used)
+var defaultConfigFileNames = []string{".jenkins-contribution-aggregator.yaml", ".jenkins-contribution-aggregator.toml"}
+
+// Prefix of the environment variables overriding the configuration (e.g. JCA_TOPSIZE)
+const configEnvPrefix = "JCA_"
+
+// Configuration file specified on the command line
+var configFileName string
+
+// Sets the flags of the command (and the global ones) that were not explicitly specified on the
+// command line. The value is taken, by order of precedence, from:
+//   - the environment variable "JCA_<FLAG NAME>" (e.g. JCA_TOPSIZE),
+//   - the section of the configuration file named after the command (e.g. "extract:"),
+//   - the top level of the configuration file,
+//   - the default value of the flag.
+//
+// The excluded accounts, the aliases and the additional contribution kinds are then loaded, as
+// they can be defined in the configuration.
+// This must be called first thing when validating the arguments of a command.
+func applyConfiguration(cmd *cobra.Command) error {
+	config, err := loadConfiguration(configFileName)
+	if err != nil {
+		return err
+	}
+
+	var applyErr error
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if applyErr != nil || flag.Changed || flag.Name == "config" || flag.Name == "help" {
+			return
+		}
+		values, source, found := configuredValues(config, cmd.Name(), flag.Name)
+		if !found {
+			return
+		}
+		// The elements of a list are set one by one on the list flags, as they can contain commas
+		// (regular expressions of "--exclude"). They are comma separated for the other flags.
+		if _, isList := flag.Value.(pflag.SliceValue); !isList {
+			values = []string{strings.Join(values, ",")}
+		}
+		for _, value := range values {
+			// Setting the value directly keeps the flag "unchanged" (not specified on the command line)
+			if err := flag.Value.Set(value); err != nil {
+				applyErr = fmt.Errorf("Invalid value \"%s\" for \"%s\" (from %s): %v", value, flag.Name, source, err)
+				return
+			}
+		}
+	})
+	if applyErr != nil {
+		return applyErr
+	}
+
+	if err := loadExclusions(); err != nil {
+		return err
+	}
+	if err := loadAliases(); err != nil {
+		return err
+	}
+	if err := loadAnonymization(); err != nil {
+		return err
+	}
+	if kindsFileName != "" {
+		return loadContributionKinds(kindsFileName)
+	}
+	return nil
+}
+
+// Reads the configuration file, in TOML if its extension is ".toml" and in YAML otherwise.
+// If no file is specified, the default file of the home directory is used when it exists.
+// The keys are flag names, a section named after a command can override the values for that command.
+func loadConfiguration(fileName string) (map[string]interface{}, error) {
+	if fileName == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		for _, defaultFileName := range defaultConfigFileNames {
+			if isFileValid(filepath.Join(home, defaultFileName)) {
+				fileName = filepath.Join(home, defaultFileName)
+				break
+			}
+		}
+		if fileName == "" {
+			return nil, nil
+		}
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read the configuration file: %v", err)
+	}
+	config := make(map[string]interface{})
+	if strings.ToLower(filepath.Ext(fileName)) == ".toml" {
+		err = toml.Unmarshal(data, &config)
+	} else {
+		err = yaml.Unmarshal(data, &config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid configuration file %s: %v", fileName, err)
+	}
+	return config, nil
+}
+
+// Returns the value of a flag from the environment or the configuration (the elements of a list), and where it was found
+func configuredValues(config map[string]interface{}, commandName string, flagName string) (values []string, source string, found bool) {
+	envName := configEnvPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+	if value, found := os.LookupEnv(envName); found {
+		return []string{value}, envName, true
+	}
+
+	if section, isSection := config[commandName].(map[string]interface{}); isSection {
+		if value, found := section[flagName]; found {
+			return configValueToStrings(value), "configuration section \"" + commandName + "\"", true
+		}
+	}
+	if value, found := config[flagName]; found {
+		if _, isSection := value.(map[string]interface{}); !isSection {
+			return configValueToStrings(value), "configuration", true
+		}
+	}
+	return nil, "", false
+}
+
+// Converts a configuration value to the format of the command line (one element per list element)
+func configValueToStrings(value interface{}) []string {
+	if list, isList := value.([]interface{}); isList {
+		var elements []string
+		for _, element := range list {
+			elements = append(elements, fmt.Sprint(element))
+		}
+		return elements
+	}
+	return []string{fmt.Sprint(value)}
+}
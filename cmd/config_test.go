@@ -0,0 +1,161 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_configuredValues(t *testing.T) {
+	config, err := loadConfiguration("../test_data/config.yaml")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		commandName string
+		flagName    string
+		env         string
+		wantValues  []string
+		wantFound   bool
+	}{
+		{"Top level value", "compare", "topSize", "", []string{"5"}, true},
+		{"Command section overrides top level", "extract", "topSize", "", []string{"3"}, true},
+		{"Environment overrides the file", "extract", "topSize", "10", []string{"10"}, true},
+		{"Boolean value", "extract", "history", "", []string{"false"}, true},
+		{"Section is not a value", "check", "extract", "", nil, false},
+		{"Not configured", "extract", "month", "", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("JCA_TOPSIZE", tt.env)
+			}
+			gotValues, _, gotFound := configuredValues(config, tt.commandName, tt.flagName)
+			assert.Equal(t, tt.wantFound, gotFound)
+			assert.Equal(t, tt.wantValues, gotValues)
+		})
+	}
+}
+
+func Test_configuredValues_toml(t *testing.T) {
+	config, err := loadConfiguration("../test_data/config.toml")
+	assert.NoError(t, err)
+
+	values, _, found := configuredValues(config, "extract", "topSize")
+	assert.True(t, found)
+	assert.Equal(t, []string{"3"}, values)
+
+	values, _, found = configuredValues(config, "compare", "topSize")
+	assert.True(t, found)
+	assert.Equal(t, []string{"5"}, values)
+
+	values, _, found = configuredValues(config, "extract", "exclude")
+	assert.True(t, found)
+	assert.Equal(t, []string{"olblak", "/^jenkins-x-bot(-test){0,1}$/"}, values)
+}
+
+func Test_loadConfiguration(t *testing.T) {
+	// No default file in the home directory
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	config, err := loadConfiguration("")
+	assert.NoError(t, err)
+	assert.Empty(t, config)
+
+	// The default file can be in TOML
+	assert.NoError(t, os.WriteFile(filepath.Join(home, ".jenkins-contribution-aggregator.toml"), []byte("topSize = 7\n"), 0644))
+	config, err = loadConfiguration("")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), config["topSize"])
+
+	_, err = loadConfiguration("unexistantFile.yaml")
+	assert.Error(t, err)
+
+	_, err = loadConfiguration("../test_data/not_a_csv.txt")
+	assert.Error(t, err)
+
+	// A YAML file with the TOML extension is invalid
+	invalidFileName := filepath.Join(home, "config.toml")
+	assert.NoError(t, os.WriteFile(invalidFileName, []byte("topSize: 5\n"), 0644))
+	_, err = loadConfiguration(invalidFileName)
+	assert.Error(t, err)
+}
+
+func Test_ExecuteExtractWithConfig_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := filepath.Join(tempDir, "top-submitters.csv")
+	t.Setenv("JCA_PERIOD", "12")
+
+	// setup the command line (the output file name is explicit and takes precedence)
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/overview.csv", "--config=../test_data/config.yaml", "--out=" + testOutputFilename})
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.Equal(t, 3, topSize, "topSize should be set by the \"extract\" section")
+	assert.Equal(t, 12, period, "period should be set by the environment")
+	assert.False(t, isOutputHistory)
+
+	content, err := os.ReadFile(testOutputFilename)
+	assert.NoError(t, err)
+	// The header and the three top submitters
+	assert.Equal(t, 4, bytes.Count(content, []byte("\n")))
+
+	// Reset the flags for the other tests
+	configFileName, topSize, period = "", 35, 12
+}
+
+func Test_ExecuteExtractWithExclusionsInConfig_integrationTest(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	// The elements of the list are not split on the commas
+	assert.NoError(t, os.WriteFile(configFile, []byte("exclude:\n  - olblak\n  - /^jenkins-x-bot(-test){0,1}$/\n"), 0644))
+	// The flag may have been specified on the command line by a previous test
+	rootCmd.PersistentFlags().Lookup("exclude").Changed = false
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/overview.csv", "--config=" + configFile, "--format=md", "--out=-"})
+	defer func() {
+		configFileName, excludePatterns, argOutputFormat = "", nil, "auto"
+		outputFileName = "top-submitters_YYYY-MM.csv"
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.Contains(t, actual.String(), "Excluded accounts: jenkins-x-bot, jenkins-x-bot-test, olblak.\n")
+}
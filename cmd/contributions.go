@@ -0,0 +1,153 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+)
+
+// Maximum number of repositories listed in the breakdown column (the others are summed)
+const maxBreakdownRepos = 3
+
+// Filters of the contributions of a long-format input (set from the command line)
+var repoPatterns []string
+var labelPatterns []string
+
+// Validates the "--repo" and "--label" filters
+func checkContributionFilterArgs() error {
+	if _, err := pivot.NewContributionFilter(repoPatterns, labelPatterns); err != nil {
+		return fmt.Errorf("Invalid filter: %v\n", err)
+	}
+	return nil
+}
+
+// Keeps the contributions of a long-format table matching the "--repo" and "--label" filters.
+// The returned table keeps the filtered contributions to break the totals down per repository.
+// The filters can't be applied to a pivot table, which has no repository nor label.
+func filterContributions(table *pivot.PivotTable) (*pivot.PivotTable, error) {
+	filter, err := pivot.NewContributionFilter(repoPatterns, labelPatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	details := table.Details()
+	if filter.IsEmpty() {
+		return table, nil
+	}
+	if details == nil {
+		return nil, fmt.Errorf("The \"--repo\" and \"--label\" filters require a long-format input (\"%s\" is a pivot table)", table.Source)
+	}
+	if filter.IsFilteringRepos() && !details.HasRepos() {
+		return nil, fmt.Errorf("\"%s\" has no \"%s\" column to filter on", table.Source, pivot.LongColumnRepo)
+	}
+	if filter.IsFilteringLabels() && !details.HasLabels() {
+		return nil, fmt.Errorf("\"%s\" has no \"%s\" column to filter on", table.Source, pivot.LongColumnLabels)
+	}
+
+	filtered := details.Filter(filter)
+	if len(filtered.Contributions()) == 0 {
+		return nil, fmt.Errorf("No contribution of \"%s\" matches the \"--repo\" and \"--label\" filters", table.Source)
+	}
+	return filtered.Pivot()
+}
+
+// Returns the line describing the contribution filters in the Markdown outputs (empty if none)
+func contributionFiltersNote() string {
+	var filters []string
+	if len(repoPatterns) > 0 {
+		filters = append(filters, "repositories: "+strings.Join(repoPatterns, ", "))
+	}
+	if len(labelPatterns) > 0 {
+		filters = append(filters, "labels: "+strings.Join(labelPatterns, ", "))
+	}
+	if len(filters) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Only the contributions matching the filters are counted (%s).\n", strings.Join(filters, "; "))
+}
+
+// Returns the name of the row a contribution is counted in, following the aliases, the exclusions
+// and the anonymization applied to the loaded table (compared regardless of the case)
+func contributionRow(user string, month time.Time) (string, bool) {
+	login := pivot.CanonicalLogin(aliases, user, month)
+	if accountFilter.Match(login) {
+		return "", false
+	}
+	return strings.ToLower(anonymizedLogin(login)), true
+}
+
+// Returns the contributions of every user per repository over the period of the extraction,
+// keyed by lower case user. Returns nil if the input has no repository or if the rows are
+// not users (grouped by affiliation).
+func repoBreakdown(table *pivot.PivotTable, inputType InputType, real_endDate string) (map[string][]pivot.RepoTotal, error) {
+	details := table.Details()
+	if details == nil || !details.HasRepos() || inputType.members != nil {
+		return nil, nil
+	}
+	_, _, startMonth, endMonth, err := getBoundaries(table, real_endDate, period, 0)
+	if err != nil {
+		return nil, err
+	}
+	from, err := pivot.ParseMonth(startMonth)
+	if err != nil {
+		return nil, err
+	}
+	to, err := pivot.ParseMonth(endMonth)
+	if err != nil {
+		return nil, err
+	}
+	return details.RepoTotals(from, to, contributionRow), nil
+}
+
+// Adds to the extracted data a column listing the repositories the users contributed to the most
+func addRepoColumn(csv_output_slice [][]string, breakdown map[string][]pivot.RepoTotal) [][]string {
+	header_row := append(append([]string{}, csv_output_slice[0]...), "Repositories")
+	output_slice := [][]string{header_row}
+	for _, row := range csv_output_slice[1:] {
+		dataRow := append(append([]string{}, row...), formatRepoTotals(breakdown[strings.ToLower(row[0])]))
+		output_slice = append(output_slice, dataRow)
+	}
+	return output_slice
+}
+
+// Formats the contributions per repository: "jenkinsci/jenkins (12), jenkinsci/remoting (3), 2 others (4)"
+func formatRepoTotals(totals []pivot.RepoTotal) string {
+	var repos []string
+	others, othersTotal := 0, 0
+	for i, total := range totals {
+		if i < maxBreakdownRepos || len(totals) == maxBreakdownRepos+1 {
+			repos = append(repos, total.Repo+" ("+strconv.Itoa(total.Total)+")")
+			continue
+		}
+		others++
+		othersTotal += total.Total
+	}
+	if others > 0 {
+		repos = append(repos, fmt.Sprintf("%d others (%d)", others, othersTotal))
+	}
+	return strings.Join(repos, ", ")
+}
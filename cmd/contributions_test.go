@@ -0,0 +1,167 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExecuteExtractLongFormatWithFilters_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := filepath.Join(tempDir, "top-submitters.md")
+	goldenMarkdownFilename, err := duplicateFile("../test_data/extract-long_reference_output.md", tempDir)
+
+	assert.NoError(t, err, "Unexpected Golden File duplication error")
+	assert.NotEmpty(t, goldenMarkdownFilename, "Failure to duplicate Golden File")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/contributions_long.csv", "--repo=jenkinsci/*", "--label=!dependencies", "--ranking", "--out=" + testOutputFilename})
+	defer func() {
+		repoPatterns, labelPatterns, isRankingOutput = nil, nil, false
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.NoError(t, isFileEquivalent(testOutputFilename, goldenMarkdownFilename))
+}
+
+func Test_ExecuteCompareLongFormat_integrationTest(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"compare", "../test_data/contributions_long.csv", "--repo=jenkinsci/git*", "--exclude-bots", "--period=3", "--compare=2", "--format=json", "--out=-"})
+	defer func() {
+		repoPatterns, isExcludingBots, argOutputFormat = nil, false, "auto"
+		period, compareWith = 12, 3
+		outputFileName = "top-submitters_YYYY-MM.csv"
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	var document jsonOutput
+	assert.NoError(t, json.Unmarshal(actual.Bytes(), &document), "Invalid JSON document")
+	assert.Equal(t, []string{"jenkinsci/git*"}, document.Metadata.Repos)
+	assert.Equal(t, []jsonEntry{
+		{Rank: 1, User: "MarkEWaite", Total: 8, Repositories: []jsonRepository{{Repository: "jenkinsci/git-plugin", Total: 5}, {Repository: "jenkinsci/git-client-plugin", Total: 3}}},
+		{Rank: 2, User: "jonesbusy", Total: 4, Repositories: []jsonRepository{{Repository: "jenkinsci/git-plugin", Total: 4}}},
+		{Rank: 3, User: "basil", Total: 1, Repositories: []jsonRepository{{Repository: "jenkinsci/git-plugin", Total: 1}}},
+	}, document.Entries)
+}
+
+func Test_ExecuteCompareLongFormatFilterKeepsLatestMonth(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"compare", "../test_data/contributions_long.csv", "--repo=jenkins-infra/helpdesk", "--period=1", "--compare=1", "--format=json", "--out=-"})
+	defer func() {
+		repoPatterns, argOutputFormat = nil, "auto"
+		period, compareWith = 12, 3
+		outputFileName = "top-submitters_YYYY-MM.csv"
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results: the helpdesk contributions end in 2023-02, the input in 2023-04
+	assert.NoError(t, error, "Unexpected failure")
+	var document jsonOutput
+	assert.NoError(t, json.Unmarshal(actual.Bytes(), &document), "Invalid JSON document")
+	assert.Equal(t, "2023-04", document.Metadata.EndMonth)
+}
+
+func Test_ExecuteExtractFilterOnPivotTable(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/overview.csv", "--label=!dependencies", "--out=-"})
+	defer func() {
+		labelPatterns = nil
+		outputFileName = "top-submitters_YYYY-MM.csv"
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.Error(t, error, "The filters should require a long-format input")
+}
+
+func Test_repoBreakdownWithTwoTables(t *testing.T) {
+	// The report, the dashboard and the combined extraction load the submitters and the commenters
+	submitters, err := loadInputPivotTable("../test_data/contributions_long.csv")
+	assert.NoError(t, err, "Unexpected load failure")
+	commenters, err := loadInputPivotTable("../test_data/commenters_long.csv")
+	assert.NoError(t, err, "Unexpected load failure")
+
+	submittersBreakdown, err := repoBreakdown(submitters, InputTypeSubmitters, "2023-04")
+	assert.NoError(t, err, "Unexpected breakdown failure")
+	assert.Equal(t, []pivot.RepoTotal{{Repo: "jenkinsci/jenkins", Total: 25}, {Repo: "jenkinsci/remoting", Total: 3}, {Repo: "jenkinsci/git-plugin", Total: 1}}, submittersBreakdown["basil"])
+
+	commentersBreakdown, err := repoBreakdown(commenters, InputTypeCommenters, "2023-04")
+	assert.NoError(t, err, "Unexpected breakdown failure")
+	assert.Equal(t, []pivot.RepoTotal{{Repo: "jenkinsci/plugin-pom", Total: 5}}, commentersBreakdown["basil"])
+}
+
+func Test_formatRepoTotals(t *testing.T) {
+	tests := []struct {
+		name   string
+		totals []pivot.RepoTotal
+		want   string
+	}{
+		{"no repository", nil, ""},
+		{"single repository", []pivot.RepoTotal{{Repo: "jenkinsci/jenkins", Total: 12}}, "jenkinsci/jenkins (12)"},
+		{
+			"one more than the maximum",
+			[]pivot.RepoTotal{{Repo: "a", Total: 4}, {Repo: "b", Total: 3}, {Repo: "c", Total: 2}, {Repo: "d", Total: 1}},
+			"a (4), b (3), c (2), d (1)",
+		},
+		{
+			"others summed",
+			[]pivot.RepoTotal{{Repo: "a", Total: 5}, {Repo: "b", Total: 4}, {Repo: "c", Total: 3}, {Repo: "d", Total: 2}, {Repo: "e", Total: 1}},
+			"a (5), b (4), c (3), 2 others (3)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatRepoTotals(tt.totals))
+		})
+	}
+}
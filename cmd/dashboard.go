@@ -0,0 +1,247 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+	"github.com/spf13/cobra"
+)
+
+//go:embed templates/dashboard.html
+var dashboardTemplates embed.FS
+
+var dashboardDirectory string
+
+// dashboardCmd represents the dashboard command
+var dashboardCmd = &cobra.Command{
+	Use:   "dashboard [submitters pivot table] [commenters pivot table]",
+	Short: "Generates a static HTML dashboard of the top submitters and commenters",
+	Long: `The DASHBOARD command renders a self-contained static HTML site from the
+submitters and the commenters pivot tables. The site can be published as is
+(on GitHub Pages for instance) from the output directory.
+
+The "index.html" page shows the top users of each pivot table (as with the COMPARE
+command) in sortable tables, with a badge for the "new" and "churned" users.
+Every top user has a page ("users/<user>.html") with the charts of their monthly
+activity. A navigation sidebar links all the pages together.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfiguration(cmd); err != nil {
+			return err
+		}
+		if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+			return err
+		}
+		if err := validateInputFiles(args); err != nil {
+			return err
+		}
+		if !isValidMonth(endMonth, isVerboseExtract) {
+			return fmt.Errorf("\"%s\" is an invalid month\n", endMonth)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		submitters, err := loadInputPivotTable(args[0])
+		if err != nil {
+			return fmt.Errorf("%s: %v", args[0], err)
+		}
+		commenters, err := loadInputPivotTable(args[1])
+		if err != nil {
+			return fmt.Errorf("%s: %v", args[1], err)
+		}
+
+		dashboard, err := buildDashboard(args, []*pivot.PivotTable{submitters, commenters}, []InputType{InputTypeSubmitters, InputTypeCommenters})
+		if err != nil {
+			return err
+		}
+		if err := writeDashboard(dashboardDirectory, dashboard); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Dashboard written to \"%s\"\n", filepath.Join(dashboardDirectory, "index.html"))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dashboardCmd)
+
+	dashboardCmd.PersistentFlags().StringVarP(&dashboardDirectory, "out", "o", "dashboard", "Output directory of the dashboard.")
+	dashboardCmd.PersistentFlags().IntVarP(&topSize, "topSize", "t", 35, "Number of top users to extract.")
+	dashboardCmd.PersistentFlags().IntVarP(&period, "period", "p", 12, "Number of months to accumulate.")
+	dashboardCmd.PersistentFlags().IntVarP(&compareWith, "compare", "c", 3, "Number of months back to compare with.")
+	dashboardCmd.PersistentFlags().StringVarP(&endMonth, "month", "m", "latest", "Month to extract top users.")
+	dashboardCmd.PersistentFlags().BoolVarP(&isVerboseExtract, "verbose", "v", false, "Displays useful info during the extraction")
+}
+
+// The data rendered by the dashboard templates
+type dashboardData struct {
+	Title       string
+	Root        string
+	Sources     []string
+	TopSize     int
+	Period      int
+	CompareWith int
+	// ExcludedAccounts are the accounts removed from the tables ("--exclude")
+	ExcludedAccounts []string
+	Sections         []dashboardSection
+}
+
+// The top users of a pivot table
+type dashboardSection struct {
+	Kind  InputType
+	Table *pivot.PivotTable
+	// EndMonth is the last month of the extraction (the tables may end on different months)
+	EndMonth string
+	Rows     []dashboardRow
+}
+
+// A line of the top users table (as generated by the COMPARE command)
+type dashboardRow struct {
+	User string
+	// Page is the file name of the page of the user (without the ".html" extension)
+	Page       string
+	Total      string
+	TotalValue int
+	Status     string
+}
+
+// The page of a user, with a chart per pivot table they are a top user of
+type dashboardUserPage struct {
+	dashboardData
+	User   string
+	Page   string
+	Charts []dashboardChart
+}
+
+type dashboardChart struct {
+	Kind   InputType
+	Total  string
+	Status string
+	SVG    template.HTML
+}
+
+// Compares the top users of every table
+func buildDashboard(sources []string, tables []*pivot.PivotTable, kinds []InputType) (dashboardData, error) {
+	dashboard := dashboardData{
+		Title:            "Jenkins Contributors Dashboard",
+		Sources:          sources,
+		TopSize:          topSize,
+		Period:           period,
+		CompareWith:      compareWith,
+		ExcludedAccounts: excludedAccounts(),
+	}
+
+	for i, table := range tables {
+		kind := kinds[i]
+		result, real_endDate, recentData := extractData(table, topSize, endMonth, period, 0, kind, isVerboseExtract)
+		if !result {
+			return dashboard, fmt.Errorf("Failed to extract data")
+		}
+		result, _, oldData := extractData(table, topSize, endMonth, period, compareWith, kind, isVerboseExtract)
+		if !result {
+			return dashboard, fmt.Errorf("Failed to extract offset-ted data")
+		}
+
+		section := dashboardSection{Kind: kind, Table: table, EndMonth: real_endDate}
+		for _, line := range compareExtractedData(recentData, oldData, kind)[1:] {
+			totalValue, _ := strconv.Atoi(line[1])
+			section.Rows = append(section.Rows, dashboardRow{User: line[0], Page: plotBaseName(line[0]), Total: line[1], TotalValue: totalValue, Status: line[2]})
+		}
+		dashboard.Sections = append(dashboard.Sections, section)
+	}
+	return dashboard, nil
+}
+
+// Writes the index page and the page of every top user
+func writeDashboard(directory string, dashboard dashboardData) error {
+	templates, err := template.ParseFS(dashboardTemplates, "templates/dashboard.html")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(directory, "users"), os.ModePerm); err != nil {
+		return fmt.Errorf("Failed to create the dashboard directory: %v", err)
+	}
+
+	if err := executeTemplateToFile(templates, "index", filepath.Join(directory, "index.html"), dashboard); err != nil {
+		return err
+	}
+
+	pages := make(map[string]*dashboardUserPage)
+	for _, section := range dashboard.Sections {
+		for _, row := range section.Rows {
+			page, found := pages[row.User]
+			if !found {
+				page = &dashboardUserPage{dashboardData: dashboard, User: row.User, Page: row.Page}
+				page.Root = "../"
+				page.Title = row.User + " - " + dashboard.Title
+				pages[row.User] = page
+			}
+
+			index := section.Table.UserIndex(row.User)
+			svg, err := svgBarGraph(section.Kind.PlotTitle+" "+row.User, section.Table.MonthLabels(), section.Table.Row(index))
+			if err != nil {
+				return err
+			}
+			total := row.Total
+			if total == "" {
+				// Churned users are not in the current extraction: their current total is reported
+				first, last, _, _, err := getBoundaries(section.Table, section.EndMonth, period, 0)
+				if err != nil {
+					return err
+				}
+				total = strconv.Itoa(section.Table.Sum(index, first, last))
+			}
+			// The SVG is generated by the plot library, not from user input
+			page.Charts = append(page.Charts, dashboardChart{Kind: section.Kind, Total: total + " " + section.Kind.TotalColumn, Status: row.Status, SVG: template.HTML(svg)})
+		}
+	}
+
+	users := make([]string, 0, len(pages))
+	for user := range pages {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	for _, user := range users {
+		if err := executeTemplateToFile(templates, "user", filepath.Join(directory, "users", pages[user].Page+".html"), pages[user]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Renders a template to a file
+func executeTemplateToFile(templates *template.Template, name string, fileName string, data interface{}) error {
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return templates.ExecuteTemplate(f, name, data)
+}
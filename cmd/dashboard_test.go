@@ -0,0 +1,97 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExecuteDashboard_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	dashboardDir := filepath.Join(tempDir, "site")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"dashboard", "../test_data/overview.csv", "../test_data/overview.csv", "--month=latest", "--period=12", "--topSize=20", "--compare=3", "--out=" + dashboardDir})
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Reset the flags for the other tests
+	topSize = 35
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+
+	index, err := os.ReadFile(filepath.Join(dashboardDir, "index.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(index), `<h2 id="submitters">Top Submitters</h2>`)
+	assert.Contains(t, string(index), `<h2 id="commenters">Top Commenters</h2>`)
+	assert.Contains(t, string(index), `<td data-value="basil"><a href="users/basil.html">basil</a></td>`)
+	assert.Contains(t, string(index), `<span class="badge badge-new">new</span>`)
+	assert.Contains(t, string(index), `<span class="badge badge-churned">churned</span>`)
+
+	userPage, err := os.ReadFile(filepath.Join(dashboardDir, "users", "basil.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(userPage), `<a href="../index.html#submitters">Top Submitters</a>`)
+	assert.Equal(t, 2, strings.Count(string(userPage), "<svg"), "One chart per pivot table expected")
+
+	// The page of a churned user reports their total over the current period
+	churnedPage, err := os.ReadFile(filepath.Join(dashboardDir, "users", "Vlatombe.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(churnedPage), `<p>98 Total_PRs over the period <span class="badge badge-churned">churned</span></p>`)
+}
+
+func Test_ExecuteDashboardWithDifferentEndMonths_integrationTest(t *testing.T) {
+	dashboardDir := filepath.Join(t.TempDir(), "site")
+
+	// setup the command line: the commenters table ends one month after the submitters one
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"dashboard", "../test_data/ends_2023-03_overview.csv", "../test_data/ends_2023-04_overview.csv", "--month=latest", "--period=2", "--topSize=1", "--compare=1", "--out=" + dashboardDir})
+	defer func() { topSize, period, compareWith = 35, 12, 3 }()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results: every section is computed over its own period
+	assert.NoError(t, error, "Unexpected failure")
+	index, err := os.ReadFile(filepath.Join(dashboardDir, "index.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(index), `<p>Over the 2 months before "2023-03".</p>`)
+	assert.Contains(t, string(index), `<p>Over the 2 months before "2023-04".</p>`)
+
+	// The churned commenter has 3 comments over 2023-03 and 2023-04
+	churnedPage, err := os.ReadFile(filepath.Join(dashboardDir, "users", "bravo.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(churnedPage), `<p>3 Total_Comments over the period <span class="badge badge-churned">churned</span></p>`)
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+)
+
+// Accounts to exclude (set from the command line or the configuration)
+var (
+	excludePatterns    []string
+	excludeFileName    string
+	isExcludingBots    bool
+	accountFilter      *pivot.AccountFilter
+	excludedAccountSet = map[string]bool{}
+)
+
+// Builds the filter of the excluded accounts from the "--exclude", "--exclude-file" and "--exclude-bots" flags.
+// This is called by applyConfiguration and resets the list of the excluded accounts.
+func loadExclusions() error {
+	excludedAccountSet = map[string]bool{}
+
+	patterns := append([]string(nil), excludePatterns...)
+	if isExcludingBots {
+		patterns = append(patterns, pivot.BotPatterns...)
+	}
+	if excludeFileName != "" {
+		f, err := os.Open(excludeFileName)
+		if err != nil {
+			return fmt.Errorf("Unable to read the exclusion file: %v", err)
+		}
+		defer f.Close()
+		filePatterns, err := pivot.ReadAccountPatterns(f)
+		if err != nil {
+			return fmt.Errorf("Unable to read the exclusion file: %v", err)
+		}
+		patterns = append(patterns, filePatterns...)
+	}
+
+	filter, err := pivot.NewAccountFilter(patterns)
+	if err != nil {
+		return fmt.Errorf("Invalid exclusion: %v", err)
+	}
+	accountFilter = filter
+	return nil
+}
+
+// Removes the excluded accounts from a table, remembering them to list them in the outputs
+func excludeAccounts(table *pivot.PivotTable) (*pivot.PivotTable, error) {
+	if accountFilter.IsEmpty() {
+		return table, nil
+	}
+	filtered, excluded, err := table.Exclude(accountFilter)
+	if err != nil {
+		return nil, err
+	}
+	if len(excluded) > 0 {
+		fmt.Fprintf(os.Stderr, "Excluded %d accounts from \"%s\"%s\n", len(excluded), table.Source, progressLogins(excluded))
+	}
+	for _, user := range excluded {
+		excludedAccountSet[user] = true
+	}
+	return filtered, nil
+}
+
+// Returns the sorted list of the accounts excluded from the loaded tables.
+// The anonymized users are not listed, as they must not be named in the outputs.
+func excludedAccounts() []string {
+	accounts := make([]string, 0, len(excludedAccountSet))
+	for account := range excludedAccountSet {
+		if pseudonymizer.IsAnonymized(account) {
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
+// Writes the excluded accounts of a CSV output to a second file, suffixed with "_excluded" (nothing if none)
+func writeExcludedAccountsCSV(outputFileName string) {
+	accounts := excludedAccounts()
+	if len(accounts) == 0 {
+		return
+	}
+	records := [][]string{{"Excluded_Account"}}
+	for _, account := range accounts {
+		records = append(records, []string{account})
+	}
+	writeCompanionCSV(outputFileName, "_excluded", records)
+}
+
+// Returns the line listing the excluded accounts in the Markdown outputs (empty if none)
+func excludedAccountsNote() string {
+	accounts := excludedAccounts()
+	if len(accounts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Excluded accounts: %s.\n", strings.Join(accounts, ", "))
+}
@@ -0,0 +1,152 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExecuteExtractWithExclusions_integrationTest(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/overview.csv", "--exclude-bots", "--exclude=olblak", "--exclude=/^jenkins-x-bot(-test){0,1}$/", "--format=md", "--out=-"})
+	defer func() {
+		excludePatterns, isExcludingBots, argOutputFormat = nil, false, "auto"
+		outputFileName = "top-submitters_YYYY-MM.csv"
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.Contains(t, actual.String(), "Excluded accounts: daniel-beck-bot, jenkins-x-bot, jenkins-x-bot-test, olblak, sauce-jenkins-bot.\n")
+	assert.NotContains(t, actual.String(), "| olblak ")
+}
+
+func Test_ExecuteTimelineWithExclusionFile_integrationTest(t *testing.T) {
+	exclusionFileName := filepath.Join(t.TempDir(), "excluded.txt")
+	assert.NoError(t, os.WriteFile(exclusionFileName, []byte("# Top users\nolblak\nBASIL\n"), 0644))
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"timeline", "../test_data/overview.csv", "--topSize=3", "--exclude-file=" + exclusionFileName, "--format=json", "--out=-"})
+	defer func() {
+		excludeFileName, topSize, argOutputFormat = "", 35, "auto"
+		timelineOutputFileName = "timeline.csv"
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	var document jsonTimeline
+	assert.NoError(t, json.Unmarshal(actual.Bytes(), &document), "Invalid JSON document")
+	assert.Equal(t, []string{"basil", "olblak"}, document.Metadata.ExcludedAccounts)
+	for _, user := range document.Users {
+		assert.NotContains(t, []string{"basil", "olblak"}, user.User)
+	}
+}
+
+func Test_ExecuteIngestAndExtractGitHubAppBots_integrationTest(t *testing.T) {
+	tempDir := t.TempDir()
+	eventsFileName := filepath.Join(tempDir, "events.csv")
+	testOutputFilename := filepath.Join(tempDir, "overview.csv")
+	events := "user,repo,created_at\nbasil,jenkinsci/jenkins,2022-12-03T10:00:00Z\nbasil,jenkinsci/jenkins,2023-01-03T10:00:00Z\ndependabot[bot],jenkinsci/jenkins,2023-01-04T10:00:00Z\n"
+	assert.NoError(t, os.WriteFile(eventsFileName, []byte(events), 0644))
+	defer func() {
+		isExcludingBots = false
+		outputFileName = "top-submitters_YYYY-MM.csv"
+	}()
+
+	// The ingested table keeps every account, the exclusions are applied when ranking
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"ingest", eventsFileName, "--exclude-bots", "--out=" + testOutputFilename})
+	assert.NoError(t, rootCmd.Execute(), "Unexpected failure")
+	output, err := os.ReadFile(testOutputFilename)
+	assert.NoError(t, err)
+	assert.Contains(t, string(output), "dependabot[bot]")
+
+	actual.Reset()
+	rootCmd.SetArgs([]string{"extract", testOutputFilename, "--exclude-bots", "--out=-"})
+	assert.NoError(t, rootCmd.Execute(), "Unexpected failure")
+	// The excluded accounts follow the extraction
+	tables := strings.Split(actual.String(), "\n\n")
+	assert.Len(t, tables, 2)
+	assert.Equal(t, "Submitter,Total_PRs\nbasil,2", tables[0])
+	assert.Equal(t, "Excluded_Account\ndependabot[bot]\n", tables[1])
+}
+
+func Test_ExecuteExtractToCSVWithExclusions_integrationTest(t *testing.T) {
+	testOutputFilename := filepath.Join(t.TempDir(), "top.csv")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/overview.csv", "--exclude=olblak", "--exclude=basil", "--out=" + testOutputFilename})
+	defer func() {
+		excludePatterns = nil
+		outputFileName = "top-submitters_YYYY-MM.csv"
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results: the excluded accounts are written next to the extraction
+	assert.NoError(t, error, "Unexpected failure")
+	excluded, err := os.ReadFile(filepath.Join(filepath.Dir(testOutputFilename), "top_excluded.csv"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Excluded_Account\nbasil\nolblak\n", string(excluded))
+}
+
+func Test_ExecuteExtractWithInvalidExclusion(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/overview.csv", "--exclude=/[/", "--out=-"})
+	defer func() {
+		excludePatterns = nil
+		outputFileName = "top-submitters_YYYY-MM.csv"
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.Error(t, error, "An invalid regular expression should be rejected")
+}
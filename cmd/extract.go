@@ -22,14 +22,13 @@ THE SOFTWARE.
 package cmd
 
 import (
-	"encoding/csv"
 	"fmt"
 	"log"
 	"os"
-	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
 	"github.com/spf13/cobra"
 )
 
@@ -42,19 +41,11 @@ var isVerboseExtract bool
 var argInputType string
 var isOutputHistory bool
 var inputType InputType
-
-type InputType uint8
-
-const (
-	InputTypeUnknown InputType = iota
-	InputTypeSubmitters
-	InputTypeCommenters
-)
-
-type totalized_record struct {
-	User string //Submitter name
-	Pr   int    //Number of PRs
-}
+var argOutputFormat string
+var commentersFileName string
+var isRankingOutput bool
+var submittersWeight float64
+var commentersWeight float64
 
 // extractCmd represents the extract command
 var extractCmd = &cobra.Command{
@@ -62,7 +53,8 @@ var extractCmd = &cobra.Command{
 	Short: "Extracts the top submitters from the supplied pivot table",
 	Long: `This command extract the top submitter for a given period (by default 12 months).
 This interval is counted, by default, from the last month available in the pivot table.
-The input file is first validated before being processed.
+The input file is first validated before being processed. It can be compressed
+with gzip or zstd. Use "-" to read it from the standard input.
 
 If not specified, the output file name is hardcoded to "top-submitters_YYYY-MM.csv". 
 The "YYYY-MM" stands for the specified end month (see "--month" flag). It is "LATEST"
@@ -75,100 +67,106 @@ available months are counted.
 The "topSize" parameter defines the number of users considered as top users.
 If more submitters with the same amount of total PRs exist ("ex aequo"), they are included in 
 the list (resulting in more thant the specified number of top users).  
+
+With the "--ranking" flag, the rank of the top users (ex aequo users share the same rank),
+their share of all the contributions of the period and the cumulative share (in percent)
+are added to the output. The Markdown and JSON outputs also give the number of active
+users and the total of their contributions over the period.
+
+When a commenters pivot table is supplied with the "--commenters" flag, the input file
+is considered as the submitters pivot table and the top contributors are ranked on a
+combined score: the number of PRs and the number of comments multiplied by their
+respective weight ("--submittersWeight" and "--commentersWeight"). The output then 
+shows the score and its breakdown per type of contribution. The period is computed
+on the submitters pivot table.
+
+With "--group-by=affiliation", the organizations of the users (as mapped in the
+"--affiliations" file) are ranked instead of the users. The users without affiliation
+are counted as "Unaffiliated".
+
+The input file can also be a long-format table (with "user", "month", "repo", "labels"
+and "count" columns). Its contributions can then be filtered with "--repo" and "--label"
+(globs, "!" to exclude), and the output lists the top repositories of every user.
+
+The output format is deduced from the extension of the output file (".md" for Markdown,
+".json" for JSON, CSV otherwise) unless specified with the "--format" flag.
+
+With "--out=-", the result is written to the standard output (in CSV unless specified
+with "--format"). The progress messages are always written to the standard error.
 `,
 	Args: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfiguration(cmd); err != nil {
+			return err
+		}
 		if err := cobra.MinimumNArgs(1)(cmd, args); err != nil {
 			return err
 		}
-		if !isFileValid(args[0]) {
+		if !isInputFileValid(args[0]) {
 			return fmt.Errorf("Invalid input file\n")
 		}
 		if !isValidMonth(endMonth, isVerboseExtract) {
 			return fmt.Errorf("\"%s\" is an invalid month\n", endMonth)
 		}
 
-		// check the input type
-		switch strings.ToLower(argInputType) {
-		case "submitters":
-			inputType = InputTypeSubmitters
-		case "commenters":
-			inputType = InputTypeCommenters
-		default:
-			inputType = InputTypeUnknown
+		if !isValidOutputFormat(argOutputFormat) {
+			return fmt.Errorf("%s is an invalid output format\n", argOutputFormat)
 		}
 
+		// check the input type
+		inputType = lookupInputType(argInputType)
+
 		if inputType == InputTypeUnknown {
 			return fmt.Errorf("%s is an invalid input type\n", argInputType)
 		}
+		if err := checkGroupByArgs(); err != nil {
+			return err
+		}
+		if err := checkContributionFilterArgs(); err != nil {
+			return err
+		}
+		if err := checkHistoryArgs(); err != nil {
+			return err
+		}
+
+		if commentersFileName != "" {
+			return checkCombinedArgs(args[0])
+		}
 
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// When called standalone, we want to give the minimal information
-		isSilent := true
-
 		inputPivotTableName := args[0]
+		standardOutput = cmd.OutOrStdout()
 
-		// Check input file
-		if !checkFile(inputPivotTableName, isSilent) {
-			return fmt.Errorf("Invalid input file.")
+		if commentersFileName != "" {
+			return runCombinedExtract(inputPivotTableName, commentersFileName)
 		}
 
-		// Extract the data (with no offset)
-		result, real_endDate, csv_output_slice := extractData(inputPivotTableName, topSize, endMonth, period, 0, inputType, isVerboseExtract)
-		if !result {
-			return fmt.Errorf("Failed to extract data")
-		}
-
-		//FIXME: change default filename when specifying another type of input
-		// If the default value is specified, update that default with the month being used for the calculation
-		if outputFileName == "top-submitters_YYYY-MM.csv" {
-			outputFileName = "top-submitters_" + strings.ToUpper(endMonth) + ".csv"
-		}
-		isMDoutput := isWithMDfileExtension(outputFileName)
-
-		if isVerboseExtract {
-			fileTypeText := "(CSV format)"
-			if isMDoutput {
-				fileTypeText = "(Markdown format)"
-			}
-			fmt.Printf("Writing extraction to \"%s\" %s\n\n", outputFileName, fileTypeText)
-		}
-
-		// Check that the output directory exists
-		dirErr := CheckDir(outputFileName)
-		if dirErr != nil {
-			return dirErr
+		// Load and check input file
+		table, err := loadInputPivotTable(inputPivotTableName)
+		if err != nil {
+			return err
 		}
 
-		if isMDoutput {
-			introduction := ""
-			if inputType == InputTypeSubmitters {
-				introduction = "# Top Submitters\n"
-				buffer := fmt.Sprintf("\nExtraction of the %d top submitters (non-bot PR creators) \nover the %d months before \"%s\".\n\n", topSize, period, real_endDate)
-				introduction = introduction + buffer
-			}
-			if inputType == InputTypeCommenters {
-				introduction = "# Top Commenters\n"
-				buffer := fmt.Sprintf("\nExtraction of the %d top (non-bot) commenters \nover the %d months before \"%s\".\n\n", topSize, period, real_endDate)
-				introduction = introduction + buffer
+		kind := inputType
+		if isGroupedByAffiliation() {
+			if table, kind, err = groupByAffiliations(table, inputType); err != nil {
+				return err
 			}
-			writeDataAsMarkdown(outputFileName, csv_output_slice, introduction, isOutputHistory, inputType)
-		} else {
-			writeCSVtoFile(outputFileName, csv_output_slice)
 		}
 
-		//if requested, write the history based the supplied top user slice
-		if isOutputHistory {
-			isCompare := false
-			historyOutputFilename := generateHistoryFilename(outputFileName, inputType, isCompare)
-
-			if err := writeHistoryOutput(historyOutputFilename, inputPivotTableName, inputType, csv_output_slice); err != nil {
-				return err
+		//FIXME: change default filename when specifying another type of input
+		// If the default value is specified, update that default with the month being used for the calculation
+		if outputFileName == "top-submitters_YYYY-MM.csv" {
+			prefix := "top-submitters_"
+			if isGroupedByAffiliation() {
+				prefix = "top-organizations_"
 			}
+			outputFileName = defaultOutputFileName(prefix, endMonth, argOutputFormat)
 		}
 
-		return nil
+		_, err = writeExtraction(table, kind, outputFileName, isOutputHistory)
+		return err
 	},
 }
 
@@ -177,182 +175,259 @@ func init() {
 	rootCmd.AddCommand(extractCmd)
 
 	// definition of flags and configuration settings.
-	extractCmd.PersistentFlags().StringVarP(&outputFileName, "out", "o", "top-submitters_YYYY-MM.csv", "Output file name. Using the \".md\" extension will generate a markdown file ")
-	extractCmd.PersistentFlags().StringVarP(&argInputType, "type", "", "submitters", "The type of data being analyzed. Can be either \"submitters\" or \"commenters\"")
+	extractCmd.PersistentFlags().StringVarP(&outputFileName, "out", "o", "top-submitters_YYYY-MM.csv", "Output file name (\"-\" for the standard output). Using the \".md\" extension will generate a markdown file ")
+	extractCmd.PersistentFlags().StringVarP(&argOutputFormat, "format", "f", "auto", "Output format. Can be \"auto\" (based on the file extension), \"csv\", \"md\" or \"json\"")
+	extractCmd.PersistentFlags().StringVarP(&argInputType, "type", "", "submitters", "The type of data being analyzed. Can be \"submitters\", \"commenters\" or any kind defined with \"--kinds\"")
 	extractCmd.PersistentFlags().IntVarP(&topSize, "topSize", "t", 35, "Number of top submitters to extract.")
 	extractCmd.PersistentFlags().IntVarP(&period, "period", "p", 12, "Number of months to accumulate.")
 	extractCmd.PersistentFlags().StringVarP(&endMonth, "month", "m", "latest", "Month to extract top submitters.")
 	extractCmd.PersistentFlags().BoolVarP(&isOutputHistory, "history", "", false, "Outputs the available activity history for the top submitters")
+	extractCmd.PersistentFlags().BoolVarP(&isRankingOutput, "ranking", "", false, "Adds the rank, the share of the period total and the cumulative share of the top submitters")
+	extractCmd.PersistentFlags().StringVarP(&commentersFileName, "commenters", "", "", "Commenters pivot table to rank the top contributors on a combined score")
+	extractCmd.PersistentFlags().Float64VarP(&submittersWeight, "submittersWeight", "", 1, "Weight of a PR in the combined score (with \"--commenters\")")
+	extractCmd.PersistentFlags().Float64VarP(&commentersWeight, "commentersWeight", "", 1, "Weight of a comment in the combined score (with \"--commenters\")")
+
+	extractCmd.PersistentFlags().StringVarP(&groupBy, "group-by", "", groupByUser, "Ranks the users (\"user\") or their organizations (\"affiliation\", requires \"--affiliations\")")
+	extractCmd.PersistentFlags().StringVarP(&affiliationsFileName, "affiliations", "", "", "YAML or CSV file mapping the users to their organizations (with \"--group-by=affiliation\")")
+
+	extractCmd.PersistentFlags().StringSliceVarP(&repoPatterns, "repo", "", nil, "Repositories to count the contributions of, as globs (\"jenkinsci/*-plugin\", \"!\" to exclude). Requires a long-format input")
+	extractCmd.PersistentFlags().StringSliceVarP(&labelPatterns, "label", "", nil, "Labels to count the contributions of, as globs (\"!dependencies\" to exclude). Requires a long-format input")
 
 	extractCmd.PersistentFlags().BoolVarP(&isVerboseExtract, "verbose", "v", false, "Displays useful info during the extraction")
 }
 
-// Extracts the top submitters for a given period and writes it to a file.
-// Offset defines the number of months before the specified endMonth the extraction must be done (needed for the COMPARE command).
-func extractData(inputFilename string, topSize int, endMonth string, period int, offset int, inputType InputType, isVerboseExtract bool) (result bool, real_endDate string, outputSlice [][]string) {
-	if isVerboseExtract {
-		fmt.Printf("Extracting from \"%s\" the %d top submitters during the last %d months\n\n", inputFilename, topSize, period)
+// Extracts the top users of the table and writes them to a CSV, Markdown or JSON file (see getOutputFormat).
+// If requested, the history of the top users and their plots are written in the same directory.
+// Returns the end month of the extraction.
+func writeExtraction(table *pivot.PivotTable, inputType InputType, outputFileName string, isHistory bool) (string, error) {
+	// Extract the data (with no offset)
+	extracted, err := extractTop(table, topSize, endMonth, period, 0, inputType, isVerboseExtract)
+	if err != nil {
+		return "", fmt.Errorf("Failed to extract data")
 	}
+	real_endDate := extracted.endMonth
 
-	records, loadErr := loadInputPivotTable(inputFilename)
-	if loadErr != nil {
-		return false, "", nil
+	if isRankingOutput {
+		summary, err := summarizePeriod(table, real_endDate, period)
+		if err != nil {
+			return "", err
+		}
+		extracted.summary = &summary
 	}
+	csv_output_slice := extracted.records()
 
-	firstDataColumn, lastDataColumn, oldestDate, mostRecentDate := getBoundaries(records, endMonth, period, offset)
+	outputFormat := getOutputFormat(outputFileName, argOutputFormat)
 
-	if strings.ToUpper(endMonth) != "LATEST" {
-		if endMonth != mostRecentDate {
-			log.Printf("Unexpected error computing boundaries (\"%s\" != \"%s\"\n", endMonth, mostRecentDate)
-			return false, "", nil
+	// The JSON entries embed the breakdown per repository, the other formats get an additional column
+	output_slice := csv_output_slice
+	if outputFormat != outputFormatJSON {
+		breakdown, err := repoBreakdown(table, inputType, real_endDate)
+		if err != nil {
+			return "", err
+		}
+		if breakdown != nil {
+			output_slice = addRepoColumn(csv_output_slice, breakdown)
 		}
 	}
 
-	//We need to make that information available to caller
-	real_endDate = mostRecentDate
-
-	fmt.Printf("Accumulating data between %s and  %s (columns %d and %d)\n",
-		oldestDate, mostRecentDate, firstDataColumn, lastDataColumn)
-
-	//Slice that will contain all the totalized records
-	var new_output_slice []totalized_record
+	if isVerboseExtract {
+		fmt.Fprintf(os.Stderr, "Writing extraction to %s %s\n\n", describeOutputFile(outputFileName), describeOutputFormat(outputFormat))
+	}
 
-	for i, dataLine := range records {
+	// Check that the output directory exists
+	dirErr := CheckDir(outputFileName)
+	if dirErr != nil {
+		return "", dirErr
+	}
 
-		//Skip header line as it has already been checked
-		if i == 0 {
-			continue
+	switch outputFormat {
+	case outputFormatMarkdown:
+		introduction := "# " + inputType.Title + "\n"
+		introduction = introduction + "\n" + inputType.markdownIntroduction(topSize, period, real_endDate) + "\n"
+		if extracted.summary != nil {
+			introduction = introduction + fmt.Sprintf("There were %d active %s over the period, for a total of %d contributions.\n", extracted.summary.ActiveUsers, inputType.rankedName(), extracted.summary.Total)
+		}
+		introduction = introduction + contributionFiltersNote() + excludedAccountsNote() + "\n"
+		writeDataAsMarkdown(outputFileName, output_slice, introduction, isHistory, inputType)
+	case outputFormatJSON:
+		document := newJSONOutput(table, extracted, 0, isHistory)
+		if err := writeJSONOutput(outputFileName, document); err != nil {
+			return "", err
 		}
+	default:
+		writeCSVtoFile(outputFileName, output_slice)
+		writeExcludedAccountsCSV(outputFileName)
+	}
 
-		recordTotal := 0
-		for ii, column := range dataLine {
-			if ii >= firstDataColumn && ii <= lastDataColumn {
-				// fmt.Printf(", %s", column)
+	//if requested, write the history based the supplied top user slice
+	if isHistory {
+		isCompare := false
+		historyOutputFilename := generateHistoryFilename(outputFileName, inputType, isCompare)
 
-				// We don't treat conversion errors or negative values as the file has already been checked
-				columnValue, _ := strconv.Atoi(column)
-				recordTotal = recordTotal + columnValue
-			}
+		if err := writeHistoryOutput(historyOutputFilename, table, inputType, csv_output_slice); err != nil {
+			return "", err
 		}
-
-		//Add the total to the full list
-		a_totalized_record := totalized_record{dataLine[0], recordTotal}
-		new_output_slice = append(new_output_slice, a_totalized_record)
 	}
 
-	// Sort the slice, based on the number of PRs, in descending order
-	sort.Slice(new_output_slice, func(i, j int) bool { return new_output_slice[i].Pr > new_output_slice[j].Pr })
+	return real_endDate, nil
+}
 
-	//Loop through list to find the top submitters (and ex-aequo) to load the final list
-	current_total := 0
-	isListComplete := false
+// Extracts the top submitters for a given period and writes it to a file.
+// Offset defines the number of months before the specified endMonth the extraction must be done (needed for the COMPARE command).
+func extractData(table *pivot.PivotTable, topSize int, endMonth string, period int, offset int, inputType InputType, isVerboseExtract bool) (result bool, real_endDate string, outputSlice [][]string) {
+	extracted, err := extractTop(table, topSize, endMonth, period, offset, inputType, isVerboseExtract)
+	if err != nil {
+		return false, "", nil
+	}
+	return true, extracted.endMonth, extracted.records()
+}
 
-	var csv_output_slice [][]string
-	var header_row []string
+// Extracts the top users of the period ending "offset" months before the end month
+func extractTop(table *pivot.PivotTable, topSize int, endMonth string, period int, offset int, inputType InputType, isVerboseExtract bool) (*extraction, error) {
+	if isVerboseExtract {
+		fmt.Fprintf(os.Stderr, "Extracting from \"%s\" the %d top submitters during the last %d months\n\n", table.Source, topSize, period)
+	}
 
-	if inputType == InputTypeSubmitters {
-		header_row = []string{"Submitter", "Total_PRs"}
+	firstDataColumn, lastDataColumn, oldestDate, mostRecentDate, err := getBoundaries(table, endMonth, period, offset)
+	if err != nil {
+		log.Printf("%v\n", err)
+		return nil, err
 	}
-	if inputType == InputTypeCommenters {
-		header_row = []string{"Commenter", "Total_Comments"}
+
+	if strings.ToUpper(endMonth) != "LATEST" && offset == 0 {
+		if endMonth != mostRecentDate {
+			log.Printf("Unexpected error computing boundaries (\"%s\" != \"%s\"\n", endMonth, mostRecentDate)
+			return nil, fmt.Errorf("Unexpected end month %s (expecting %s)", mostRecentDate, endMonth)
+		}
 	}
 
-	csv_output_slice = append(csv_output_slice, header_row)
-	for i, total_record := range new_output_slice {
-		if i < topSize {
-			current_total = total_record.Pr
+	fmt.Fprintf(os.Stderr, "Accumulating data between %s and  %s (columns %d and %d)\n",
+		oldestDate, mostRecentDate, firstDataColumn+1, lastDataColumn+1)
 
-			var work_row []string
-			work_row = append(work_row, total_record.User, strconv.Itoa(total_record.Pr))
-			csv_output_slice = append(csv_output_slice, work_row)
-		} else {
-			if !isListComplete {
-				if current_total == total_record.Pr {
-					//This is an ex-aequo, so add it to the list
-					var work_row []string
-					work_row = append(work_row, total_record.User, strconv.Itoa(total_record.Pr))
-					csv_output_slice = append(csv_output_slice, work_row)
-				} else {
-					// we have all we need
-					isListComplete = true
-				}
-			}
+	// Totalize the records over the period and keep the top submitters (and ex-aequo)
+	topTotals := pivot.Top(table.Totals(firstDataColumn, lastDataColumn), topSize)
+
+	return newExtraction(inputType, mostRecentDate, topTotals), nil
+}
+
+// Activity of all the users over the extraction period
+type periodSummary struct {
+	ActiveUsers int
+	Total       int
+}
+
+// Computes the number of active users and the total of their contributions over the period ending at the given month
+func summarizePeriod(table *pivot.PivotTable, real_endDate string, period int) (periodSummary, error) {
+	firstDataColumn, lastDataColumn, _, _, err := getBoundaries(table, real_endDate, period, 0)
+	if err != nil {
+		return periodSummary{}, err
+	}
+
+	var summary periodSummary
+	for _, total := range table.Totals(firstDataColumn, lastDataColumn) {
+		if total.Total > 0 {
+			summary.ActiveUsers++
+			summary.Total += total.Total
 		}
 	}
+	return summary, nil
+}
 
-	return true, real_endDate, csv_output_slice
+// Formats the percentage of the value in the total with two decimals
+func formatPercentage(value int, total int) string {
+	if total == 0 {
+		return "0.00"
+	}
+	return strconv.FormatFloat(float64(value)*100/float64(total), 'f', 2, 64)
 }
 
-// Opens and reads the input as a CSV file
-func loadInputPivotTable(inputFilename string) (loadedRecords [][]string, err error) {
-	//TODO: add some unit tests here ?
-	//At this stage of the processing, we assume that the input file is correctly formatted
-	f, err := os.Open(inputFilename)
+// Loads the pivot table from the input file and checks that it can be processed.
+// The table is loaded and validated once and then passed to the various processing steps.
+// The anonymized users (see "--anonymize" and "--opt-out") are replaced by their pseudonym.
+func loadInputPivotTable(inputFilename string) (*pivot.PivotTable, error) {
+	table, err := loadPivotTable(inputFilename)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to read input file "+inputFilename+"\n", err)
+		return nil, err
 	}
-	defer f.Close()
+	return anonymizeUsers(table)
+}
 
-	r := csv.NewReader(f)
-	records, err := r.ReadAll()
+// Loads the pivot table from the input file, checks it and applies the contribution filters
+// (long-format input), the aliases and the exclusions (for the commands ranking the users)
+func loadPivotTable(inputFilename string) (*pivot.PivotTable, error) {
+	table, err := pivot.Load(inputFilename)
 	if err != nil {
-		return nil, fmt.Errorf("Unexpected error loading"+inputFilename+"\n", err)
+		return nil, err
+	}
+	if table, err = filterContributions(table); err != nil {
+		return nil, err
 	}
 
-	return records, nil
+	if err := checkTable(table); err != nil {
+		return nil, fmt.Errorf("Invalid input file. %v", err)
+	}
+	if table, err = mergeAliases(table); err != nil {
+		return nil, err
+	}
+	return excludeAccounts(table)
+}
+
+// Loads the pivot table from the input file and checks it, without applying the aliases nor
+// the exclusions (for the commands writing a pivot table, which must keep every account)
+func loadRawPivotTable(inputFilename string) (*pivot.PivotTable, error) {
+	table, err := pivot.Load(inputFilename)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTable(table); err != nil {
+		return nil, fmt.Errorf("Invalid input file. %v", err)
+	}
+	return table, nil
 }
 
 // Based on the number of months requested, computes the start/end column and associated date for the given dataset.
-// Offset defines the number of months before the specified endMonth the extraction must be done
-func getBoundaries(records [][]string, endMonthStr string, period int, offset int) (startColumn int, endColumn int, startMonth string, endMonth string) {
-	isWithOffset := (offset != 0)
-	nbrOfColumns := len(records[0])
-
-	if strings.ToUpper(endMonthStr) == "LATEST" {
-		endColumn = nbrOfColumns - 1
-	} else {
+// Offset defines the number of months before the specified endMonth the extraction must be done.
+// The period and the offset are counted in calendar months, whatever the columns available in the table.
+// The returned columns are month indexes in the pivot table (starting at 0).
+func getBoundaries(table *pivot.PivotTable, endMonthStr string, period int, offset int) (startColumn int, endColumn int, startMonth string, endMonth string, err error) {
+	nbrOfColumns := table.NbrOfMonths()
+
+	if nbrOfColumns == 0 {
+		return 0, 0, "", "", fmt.Errorf("No monthly data available")
+	}
+
+	firstAvailableMonth := table.Month(0)
+	lastMonth := table.Month(nbrOfColumns - 1)
+	if strings.ToUpper(endMonthStr) != "LATEST" {
 		// Search the requested end month.
-		endColumn = searchStringMonth(records[0], endMonthStr)
+		requestedMonth, parseErr := pivot.ParseMonth(endMonthStr)
 		//If not found, reset to "latest"
-		if endColumn == -1 {
-			fmt.Printf("Warning: %s not found in dataset, reverting to latest available month\n", endMonthStr)
-			endColumn = nbrOfColumns - 1
+		if parseErr != nil || table.MonthIndex(requestedMonth) == -1 {
+			fmt.Fprintf(os.Stderr, "Warning: %s not found in dataset, reverting to latest available month\n", endMonthStr)
+		} else {
+			lastMonth = requestedMonth
 		}
 	}
 
-	if isWithOffset {
-		endColumn = endColumn - offset
-		if endColumn <= 0 {
-			fmt.Printf("FATAL: requested offset-ted end period not available.\n")
-			return 0, 0, "", ""
-		}
+	lastMonth = pivot.AddMonths(lastMonth, -offset)
+	if lastMonth.Before(firstAvailableMonth) {
+		return 0, 0, "", "", fmt.Errorf("FATAL: requested offset-ted end period not available.")
 	}
 
-	if period >= nbrOfColumns {
-		period = 0
+	firstMonth := firstAvailableMonth
+	if period > 0 {
+		if periodStart := pivot.AddMonths(lastMonth, -(period - 1)); periodStart.After(firstAvailableMonth) {
+			firstMonth = periodStart
+		}
 	}
 
-	if period == 0 {
-		startColumn = 1
-	} else {
-		startColumn = (endColumn - period) + 1
+	startColumn, endColumn = table.ColumnsBetween(firstMonth, lastMonth)
+	if startColumn == -1 {
+		return 0, 0, "", "", fmt.Errorf("No monthly data available between %s and %s", pivot.FormatMonth(firstMonth), pivot.FormatMonth(lastMonth))
 	}
 
-	startMonth = records[0][startColumn]
-	endMonth = records[0][endColumn]
-
-	return startColumn, endColumn, startMonth, endMonth
-}
+	startMonth = pivot.FormatMonth(firstMonth)
+	endMonth = pivot.FormatMonth(lastMonth)
 
-// Searches the loaded records for the request month string
-func searchStringMonth(headerRecords []string, endMonthStr string) (endColumn int) {
-	nbrOfColumns := len(headerRecords)
-	endColumn = -1 //not found value
-	for i := 0; i < nbrOfColumns; i++ {
-		if headerRecords[i] == endMonthStr {
-			endColumn = i
-			break
-		}
-	}
-	return endColumn
+	return startColumn, endColumn, startMonth, endMonth, nil
 }
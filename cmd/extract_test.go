@@ -23,10 +23,13 @@ package cmd
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -42,6 +45,13 @@ var records_2 = [][]string{
 	{"AScripnic", "1", "2"},
 }
 
+// Months 2022-03 to 2022-05 are missing
+var records_gap = [][]string{
+	{"", "2022-01", "2022-02", "2022-06", "2022-07"},
+	{"0x41head", "1", "2", "3", "4"},
+	{"AScripnic", "1", "2", "3", "4"},
+}
+
 var resultSlice_1 = [][]string{
 	{"Submitter", "Total_PRs"},
 	{"0x41head", "78"},
@@ -49,10 +59,10 @@ var resultSlice_1 = [][]string{
 	{"ChadiEM", "8"},
 	{"Artmorse", "6"},
 	{"Abingcbc", "5"},
-	{"CatherineKiiru", "4"},
-	{"AndKiel", "4"},
 	{"Absh-Day", "4"},
+	{"AndKiel", "4"},
 	{"BorisYaoA", "4"},
+	{"CatherineKiiru", "4"},
 }
 
 func Test_getBoundaries(t *testing.T) {
@@ -70,71 +80,85 @@ func Test_getBoundaries(t *testing.T) {
 		wantEndColumn   int
 		wantStartMonth  string
 		wantEndMonth    string
+		wantErr         bool
 	}{
 		{
 			"Normal case",
 			args{records: records_1, endMonthStr: "latest", months: 12, offset: 0},
-			5, 16, "2022-05", "2023-04",
+			4, 15, "2022-05", "2023-04", false,
 		},
 		{
 			"Get all available months",
 			args{records: records_1, endMonthStr: "latest", months: 0, offset: 0},
-			1, 16, "2022-01", "2023-04",
+			0, 15, "2022-01", "2023-04", false,
 		},
 		{
 			"Get more months than available",
 			args{records: records_1, endMonthStr: "latest", months: 20, offset: 0},
-			1, 16, "2022-01", "2023-04",
+			0, 15, "2022-01", "2023-04", false,
 		},
 		{
 			"Specify end month - normal case",
 			args{records: records_1, endMonthStr: "2023-02", months: 6, offset: 0},
-			9, 14, "2022-09", "2023-02",
+			8, 13, "2022-09", "2023-02", false,
 		},
 		{
 			"Specify end month - get all available months",
 			args{records: records_1, endMonthStr: "2023-02", months: 0, offset: 0},
-			1, 14, "2022-01", "2023-02",
+			0, 13, "2022-01", "2023-02", false,
 		},
 		{
 			"Specify end month - get more months than available",
 			args{records: records_1, endMonthStr: "2023-02", months: 20, offset: 0},
-			1, 14, "2022-01", "2023-02",
+			0, 13, "2022-01", "2023-02", false,
 		},
 		{
 			"Specify end month - end month not found",
 			args{records: records_1, endMonthStr: "2023-08", months: 12, offset: 0},
-			5, 16, "2022-05", "2023-04",
+			4, 15, "2022-05", "2023-04", false,
 		},
 		{
 			"short month set",
 			args{records: records_2, endMonthStr: "latest", months: 12, offset: 0},
-			1, 2, "2022-01", "2022-02",
+			0, 1, "2022-01", "2022-02", false,
 		},
 		{
 			"Normal case with offset",
 			args{records: records_1, endMonthStr: "latest", months: 12, offset: 1},
-			4, 15, "2022-04", "2023-03",
+			3, 14, "2022-04", "2023-03", false,
 		},
 		{
 			"Normal case with endMonth and offset",
 			args{records: records_1, endMonthStr: "2023-02", months: 6, offset: 1},
-			8, 13, "2022-08", "2023-01",
+			7, 12, "2022-08", "2023-01", false,
 		},
 		{
 			"endMonth and offset out od bound",
 			args{records: records_1, endMonthStr: "2023-02", months: 6, offset: 16},
-			0, 0, "", "",
+			0, 0, "", "", true,
+		},
+		{
+			"Gaps in the months are counted as calendar months",
+			args{records: records_gap, endMonthStr: "latest", months: 3, offset: 0},
+			4, 6, "2022-05", "2022-07", false,
+		},
+		{
+			"Offset in calendar months with gaps",
+			args{records: records_gap, endMonthStr: "latest", months: 2, offset: 5},
+			0, 1, "2022-01", "2022-02", false,
 		},
 		{
 			"offset with latest out of dataset",
 			args{records: records_1, endMonthStr: "latest", months: 12, offset: 16},
-			0, 0, "", "",
+			0, 0, "", "", true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotStartColumn, gotEndColumn, gotStartMonth, gotEndMonth := getBoundaries(tt.args.records, tt.args.endMonthStr, tt.args.months, tt.args.offset)
+			gotStartColumn, gotEndColumn, gotStartMonth, gotEndMonth, err := getBoundaries(loadTestTable(t, tt.args.records), tt.args.endMonthStr, tt.args.months, tt.args.offset)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getBoundaries() error = %v, wantErr %v", err, tt.wantErr)
+			}
 			if gotStartColumn != tt.wantStartColumn {
 				t.Errorf("getBoundaries() gotStartColumn = %v, want %v", gotStartColumn, tt.wantStartColumn)
 			}
@@ -183,7 +207,10 @@ func Test_extractData(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotResult, gotReal_endDate, gotOutputSlice := extractData(tt.args.inputFilename, tt.args.topSize, tt.args.endMonth, tt.args.period, tt.args.offset, tt.args.inputType, tt.args.isVerboseExtract)
+			table, err := pivot.Load(tt.args.inputFilename)
+			assert.NoError(t, err, "Unexpected error loading the pivot table")
+
+			gotResult, gotReal_endDate, gotOutputSlice := extractData(table, tt.args.topSize, tt.args.endMonth, tt.args.period, tt.args.offset, tt.args.inputType, tt.args.isVerboseExtract)
 			if gotResult != tt.wantResult {
 				t.Errorf("extractData() gotResult = %v, want %v", gotResult, tt.wantResult)
 			}
@@ -243,6 +270,118 @@ func Test_ExecuteCommentersExtractToMarkdown_integrationTest(t *testing.T) {
 	assert.NoError(t, isFileEquivalent(testOutputFilename, goldenMarkdownFilename))
 }
 
+func Test_ExecuteCommentersExtractToStdout_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := filepath.Join(tempDir, "extract_stdout_output.md")
+	goldenMarkdownFilename, err := duplicateFile("../test_data/extract-commenters_reference_output.md", tempDir)
+
+	assert.NoError(t, err, "Unexpected Golden File duplication error")
+	assert.NotEmpty(t, goldenMarkdownFilename, "Failure to duplicate Golden File")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/overview.csv", "--month=latest", "--period=12", "--topSize=35", "--history=false", "--type=commenters", "--format=md", "--out=-"})
+	defer func() { argOutputFormat = "auto" }()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results: only the extraction is written to the standard output
+	assert.NoError(t, error, "Unexpected failure")
+	assert.NoError(t, os.WriteFile(testOutputFilename, actual.Bytes(), 0644))
+	assert.NoError(t, isFileEquivalent(testOutputFilename, goldenMarkdownFilename))
+}
+
+func Test_ExecuteCommentersExtractFromStdin_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := filepath.Join(tempDir, "extract_stdin_output.md")
+	goldenMarkdownFilename, err := duplicateFile("../test_data/extract-commenters_reference_output.md", tempDir)
+
+	assert.NoError(t, err, "Unexpected Golden File duplication error")
+	assert.NotEmpty(t, goldenMarkdownFilename, "Failure to duplicate Golden File")
+
+	// The pivot table is piped to the standard input
+	input, err := os.Open("../test_data/overview.csv")
+	assert.NoError(t, err)
+	defer input.Close()
+	savedStdin := os.Stdin
+	os.Stdin = input
+	defer func() { os.Stdin = savedStdin }()
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "-", "--month=latest", "--period=12", "--topSize=35", "--history=false", "--type=commenters", "--out=" + testOutputFilename})
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.NoError(t, isFileEquivalent(testOutputFilename, goldenMarkdownFilename))
+}
+
+func Test_ExecuteSubmittersExtractWithRanking_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := filepath.Join(tempDir, "extract_ranking_output.md")
+	goldenMarkdownFilename, err := duplicateFile("../test_data/extract-ranking_reference_output.md", tempDir)
+
+	assert.NoError(t, err, "Unexpected Golden File duplication error")
+	assert.NotEmpty(t, goldenMarkdownFilename, "Failure to duplicate Golden File")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/overview.csv", "--month=latest", "--period=12", "--topSize=10", "--history=false", "--type=submitters", "--ranking", "--out=" + testOutputFilename})
+	defer func() { isRankingOutput = false; topSize = 35 }()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.NoError(t, isFileEquivalent(testOutputFilename, goldenMarkdownFilename))
+}
+
+func Test_extractionRecords_ranking(t *testing.T) {
+	extracted := newExtraction(InputTypeSubmitters, "2023-03", []pivot.Total{
+		{User: "alpha", Total: 5},
+		{User: "bravo", Total: 5},
+		{User: "charly", Total: 2},
+	})
+	extracted.summary = &periodSummary{ActiveUsers: 4, Total: 16}
+
+	got := extracted.records()
+
+	assert.Equal(t, [][]string{
+		{"Submitter", "Total_PRs", "Rank", "Share_%", "Cumulative_%"},
+		{"alpha", "5", "1", "31.25", "31.25"},
+		{"bravo", "5", "1", "31.25", "62.50"},
+		{"charly", "2", "3", "12.50", "75.00"},
+	}, got)
+}
+
+func Test_summarizePeriod(t *testing.T) {
+	table := loadTestTable(t, [][]string{
+		{"", "2023-01", "2023-02", "2023-03"},
+		{"alpha", "10", "3", "2"},
+		{"bravo", "4", "0", "1"},
+		{"charly", "1", "0", "0"},
+	})
+
+	summary, err := summarizePeriod(table, "2023-03", 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, periodSummary{ActiveUsers: 2, Total: 6}, summary)
+}
+
 func Test_ExecuteCommentersExtractToMarkdownWithHistory_integrationTest(t *testing.T) {
 	// Setup test environment
 	tempDir := t.TempDir()
@@ -360,3 +499,46 @@ func Test_ExecuteExtractWithInvalidEndMonth_mustFail(t *testing.T) {
 }
 
 //TODO: integration test for CSV output
+
+func Test_ExecuteCombinedExtractToMarkdown_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := tempDir + "extract_markdown_output.md"
+	goldenMarkdownFilename, err := duplicateFile("../test_data/extract-combined_reference_output.md", tempDir)
+
+	assert.NoError(t, err, "Unexpected Golden File duplication error")
+	assert.NotEmpty(t, goldenMarkdownFilename, "Failure to duplicate Golden File")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/merged_overview_reference.csv", "--commenters=../test_data/commenters_overview.csv", "--submittersWeight=2", "--commentersWeight=0.5", "--month=latest", "--period=3", "--topSize=3", "--history=false", "--type=submitters", "--out=" + testOutputFilename})
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Reset the flags for the other tests
+	commentersFileName, submittersWeight, commentersWeight = "", 1, 1
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.NoError(t, isFileEquivalent(testOutputFilename, goldenMarkdownFilename))
+}
+
+func Test_ExecuteCombinedExtractWithHistory_mustFail(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/merged_overview_reference.csv", "--commenters=../test_data/commenters_overview.csv", "--history=true", "--type=submitters"})
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Reset the flags for the other tests
+	commentersFileName, isOutputHistory = "", false
+
+	assert.Error(t, error, "Function call should have failed")
+	assert.Contains(t, actual.String(), "Error: The history is not available for a combined extraction")
+}
@@ -0,0 +1,245 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+)
+
+// The top users of an EXTRACT or COMPARE extraction.
+// The CSV, Markdown and JSON outputs are all derived from it.
+type extraction struct {
+	kind InputType
+	// endMonth is the last month of the extraction
+	endMonth string
+	users    []extractedUser
+	// isCompare is set for a COMPARE extraction (with a status per user)
+	isCompare bool
+	// summary is the activity of all the users over the period (with "--ranking")
+	summary *periodSummary
+	// isMovement is set when the previous ranks and totals are computed (with "--movement")
+	isMovement bool
+}
+
+// A user of an extraction
+type extractedUser struct {
+	user string
+	// total is the number of contributions over the period (also for the churned users)
+	total int
+	// rank is the rank in the top (ex aequo users share the same rank), 0 for the churned users
+	rank   int
+	status string
+	// previousRank (0 if not in the top) and previousTotal are the ones of the compared period
+	previousRank  int
+	previousTotal int
+}
+
+// Builds the extraction of the top totals (sorted by descending total)
+func newExtraction(kind InputType, endMonth string, totals []pivot.Total) *extraction {
+	values := make([]int, len(totals))
+	for i, total := range totals {
+		values[i] = total.Total
+	}
+	ranks := computeRanks(values)
+
+	e := &extraction{kind: kind, endMonth: endMonth}
+	for i, total := range totals {
+		e.users = append(e.users, extractedUser{user: total.User, total: total.Total, rank: ranks[i]})
+	}
+	return e
+}
+
+// Returns the user of the extraction with the given login (nil if not found)
+func (e *extraction) find(user string) *extractedUser {
+	for i := range e.users {
+		if e.users[i].user == user {
+			return &e.users[i]
+		}
+	}
+	return nil
+}
+
+// Returns true if the user is in the compared top only (COMPARE)
+func (u extractedUser) isChurned() bool {
+	return u.rank == 0
+}
+
+// Returns the change of rank since the compared period: "▲" when rising, "▼" when declining,
+// empty if the user is not in both tops
+func (u extractedUser) rankChange() string {
+	if u.rank == 0 || u.previousRank == 0 {
+		return ""
+	}
+	switch {
+	case u.rank < u.previousRank:
+		return fmt.Sprintf("▲%d", u.previousRank-u.rank)
+	case u.rank > u.previousRank:
+		return fmt.Sprintf("▼%d", u.rank-u.previousRank)
+	default:
+		return "="
+	}
+}
+
+// Returns the change of the total since the compared period, in percent (false if there was no previous contribution)
+func (u extractedUser) changePercent() (float64, bool) {
+	if u.previousTotal == 0 {
+		return 0, false
+	}
+	return float64(u.total-u.previousTotal) * 100 / float64(u.previousTotal), true
+}
+
+// Returns the accumulated totals of the users, in the order of the extraction
+func (e *extraction) cumulativeTotals() []int {
+	cumulative := make([]int, len(e.users))
+	sum := 0
+	for i, u := range e.users {
+		sum += u.total
+		cumulative[i] = sum
+	}
+	return cumulative
+}
+
+// Compares the top users of the current period with the ones of the compared period.
+// The users who entered the top are "new", the users who left it are "churned" (with their total of the current period).
+func compareExtractions(table *pivot.PivotTable, recent *extraction, old *extraction) (*extraction, error) {
+	currentTotals, err := windowTotals(table, recent.endMonth, period, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	compared := &extraction{kind: recent.kind, endMonth: recent.endMonth, isCompare: true}
+	for _, u := range recent.users {
+		if old.find(u.user) == nil {
+			u.status = statusNew
+		}
+		compared.users = append(compared.users, u)
+	}
+	for _, u := range old.users {
+		if recent.find(u.user) == nil {
+			compared.users = append(compared.users, extractedUser{user: u.user, total: currentTotals[u.user], status: statusChurned})
+		}
+	}
+	return compared, nil
+}
+
+// Replaces the status of the compared users with a detailed one, based on their activity in the full pivot table:
+//   - a "churned" user is "dropped" if still active in the current period (but below the top), "inactive" otherwise,
+//   - a "new" user is "returning" if inactive during the compared period but active before it.
+func refineCompareStatus(table *pivot.PivotTable, compared *extraction) error {
+	previousTotals, err := windowTotals(table, compared.endMonth, period, compareWith)
+	if err != nil {
+		return err
+	}
+	previousStart, _, _, _, err := getBoundaries(table, compared.endMonth, period, compareWith)
+	if err != nil {
+		return err
+	}
+
+	for i := range compared.users {
+		u := &compared.users[i]
+		switch u.status {
+		case statusChurned:
+			if u.total > 0 {
+				u.status = statusDropped
+			} else {
+				u.status = statusInactive
+			}
+		case statusNew:
+			index := table.UserIndex(u.user)
+			if previousTotals[u.user] == 0 && index != -1 && previousStart > 0 && table.Sum(index, 0, previousStart-1) > 0 {
+				u.status = statusReturning
+			}
+		}
+	}
+	return nil
+}
+
+// Sets the rank and the total of the compared users in the compared period.
+// The totals of the users outside of the compared top are computed from the pivot table.
+func addMovement(table *pivot.PivotTable, compared *extraction, old *extraction) error {
+	previousTotals, err := windowTotals(table, compared.endMonth, period, compareWith)
+	if err != nil {
+		return err
+	}
+	for i := range compared.users {
+		u := &compared.users[i]
+		if previous := old.find(u.user); previous != nil {
+			u.previousRank = previous.rank
+		}
+		u.previousTotal = previousTotals[u.user]
+	}
+	compared.isMovement = true
+	return nil
+}
+
+// Returns the CSV records of the extraction (header included)
+func (e *extraction) records() [][]string {
+	header := []string{e.kind.Label, e.kind.TotalColumn}
+	if e.isCompare {
+		header = []string{e.kind.Label, e.kind.CompareTotalColumn, e.kind.CompareStatusColumn}
+	}
+	if e.summary != nil {
+		header = append(header, "Rank", "Share_%", "Cumulative_%")
+	}
+	if e.isMovement {
+		header = append(header, "Previous_Rank", "Rank", "Rank_Change", "Previous_Total", "Change", "Change_%")
+	}
+
+	records := [][]string{header}
+	cumulative := e.cumulativeTotals()
+	for i, u := range e.users {
+		total := strconv.Itoa(u.total)
+		if e.isCompare && u.isChurned() {
+			// The churned users are not in the current top
+			total = ""
+		}
+		record := []string{u.user, total}
+		if e.isCompare {
+			record = append(record, u.status)
+		}
+		if e.summary != nil {
+			record = append(record, strconv.Itoa(u.rank), formatPercentage(u.total, e.summary.Total), formatPercentage(cumulative[i], e.summary.Total))
+		}
+		if e.isMovement {
+			percentChange := ""
+			if change, found := u.changePercent(); found {
+				percentChange = fmt.Sprintf("%+.2f", change)
+			}
+			record = append(record, formatRank(u.previousRank), formatRank(u.rank), u.rankChange(),
+				strconv.Itoa(u.previousTotal), fmt.Sprintf("%+d", u.total-u.previousTotal), percentChange)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// Returns the percentage of the value in the total, rounded to two decimals
+func percentage(value int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(value)*10000/float64(total)) / 100
+}
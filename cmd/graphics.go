@@ -22,67 +22,152 @@ THE SOFTWARE.
 package cmd
 
 import (
-	"fmt"
+	"bytes"
+	"os"
 	"path"
-	"strconv"
+	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
 	"gonum.org/v1/plot"
 	"gonum.org/v1/plot/plotter"
 	"gonum.org/v1/plot/plotutil"
 	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
 )
 
-func plotAllHistoryFiles(plotDirectory string, historicDataSlice [][]string, dataType InputType) error {
+// Maximum number of series of a stacked bar graph (the others are summed as "Others")
+const maxStackedSeries = 8
 
-	var header []string
+// Name of the stacked bar graph of all the top organizations
+const organizationsPlotName = "top_organizations"
 
-	for row, historyRow := range historicDataSlice {
-		if row == 0 {
-			header = historyRow[1:]
-		} else {
-			err := plot_bargraph(plotDirectory, historyRow[0], dataType, header, historyRow[1:])
-			if err != nil {
-				return err
-			}
+// Plots the history of every user of the table in the specified directory.
+// The organizations (kinds grouped by affiliation) are plotted stacked by member, along with
+// a stacked bar graph of all the organizations of the table.
+func plotAllHistoryFiles(plotDirectory string, history *pivot.PivotTable, dataType InputType) error {
+
+	header := history.MonthLabels()
+
+	if dataType.members != nil {
+		return plotOrganizations(plotDirectory, history, dataType)
+	}
+
+	for row := 0; row < history.NbrOfUsers(); row++ {
+		err := plot_bargraph(plotDirectory, history.User(row), dataType, header, history.Row(row))
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// Plots the history of every organization of the table stacked by member, and all the organizations stacked together
+func plotOrganizations(plotDirectory string, history *pivot.PivotTable, dataType InputType) error {
+	header := history.MonthLabels()
+
+	var organizations []string
+	var organizationValues [][]int
+	for row := 0; row < history.NbrOfUsers(); row++ {
+		organization := plotTitleName(history.User(row))
+		members, err := dataType.members(organization)
+		if err != nil {
+			return err
+		}
+
+		var names []string
+		var values [][]int
+		for member := 0; member < members.NbrOfUsers(); member++ {
+			names = append(names, members.User(member))
+			values = append(values, members.Row(member))
+		}
+		plotFileName := path.Join(plotDirectory, plotBaseName(organization)+".png")
+		if err := plotStackedBargraph(plotFileName, dataType.PlotTitle+" "+organization, header, names, values); err != nil {
+			return err
+		}
+
+		organizations = append(organizations, organization)
+		organizationValues = append(organizationValues, history.Row(row))
+	}
+
+	plotFileName := path.Join(plotDirectory, organizationsPlotName+".png")
+	return plotStackedBargraph(plotFileName, dataType.PlotTitle+" organization", header, organizations, organizationValues)
+}
+
+// Returns the name of a history line without the status added by the COMPARE command
+func plotTitleName(name string) string {
+	if index := strings.LastIndex(name, " ("); index > 0 && strings.HasSuffix(name, ")") {
+		name = name[:index]
+	}
+	return name
+}
+
+// Returns the name of the plot file of a history line: the name of the user (or organization),
+// without the status added by the COMPARE command, the path separators and the "#" of the
+// numbered pseudonyms (which would break the links to the file)
+func plotBaseName(name string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", "#", "").Replace(plotTitleName(name))
+}
+
 // TODO: add type for legends
 // TODO: how is the data passed so that it can be formatted
 // TODO: add parameter to limit the size of the data displayed
 // Plots the passed data in a png file named after the user in the specified directory
-func plot_bargraph(plotDirectory string, name string, dataType InputType, xLabels []string, values []string) error {
-
-	p := plot.New()
+func plot_bargraph(plotDirectory string, name string, dataType InputType, xLabels []string, values []int) error {
 
 	//In case of a compare, the name is appended with "new" or "churned". So we need to clean it up
-	name_element := strings.Split(name, " ")
-	cleanedName := name_element[0]
+	cleanedName := plotTitleName(name)
 
-	plotFileName := path.Join(plotDirectory, cleanedName+".png")
+	plotFileName := path.Join(plotDirectory, plotBaseName(name)+".png")
 
-	if dataType == InputTypeCommenters {
-		p.Title.Text = "Comments by " + cleanedName
-	} else {
-	p.Title.Text = "Submissions by " + cleanedName
+	p, err := newBarGraph(dataType.PlotTitle+" "+cleanedName, xLabels, values)
+	if err != nil {
+		return err
 	}
-	p.Y.Label.Text = "Count"
 
-	w := vg.Points(20)
+	return p.Save(10*vg.Inch, 6*vg.Inch, plotFileName)
+}
 
-	floatValues, err := convertValuesToInts(values)
+// Renders the bar graph of the passed data as an SVG element (to be embedded in an HTML page)
+func svgBarGraph(title string, xLabels []string, values []int) (string, error) {
+	p, err := newBarGraph(title, xLabels, values)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	writer, err := p.WriterTo(10*vg.Inch, 4*vg.Inch, "svg")
+	if err != nil {
+		return "", err
+	}
+	var buffer bytes.Buffer
+	if _, err := writer.WriteTo(&buffer); err != nil {
+		return "", err
+	}
+
+	// Drop the XML prolog, not allowed in an HTML document
+	svg := buffer.String()
+	if start := strings.Index(svg, "<svg"); start > 0 {
+		svg = svg[start:]
 	}
-	groupA := plotter.Values(floatValues)
+	return svg, nil
+}
+
+// Builds the bar graph of the monthly values
+func newBarGraph(title string, xLabels []string, values []int) (*plot.Plot, error) {
+	p := plot.New()
+
+	p.Title.Text = title
+	p.Y.Label.Text = "Count"
+
+	w := vg.Points(20)
+
+	groupA := plotter.Values(convertValuesToFloats(values))
 
 	barsA, err := plotter.NewBarChart(groupA, w)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	barsA.LineStyle.Width = vg.Length(0)
 	barsA.Color = plotutil.Color(0)
@@ -92,9 +177,84 @@ func plot_bargraph(plotDirectory string, name string, dataType InputType, xLabel
 
 	p.NominalX(simplifiedLabels...)
 
+	return p, nil
+}
+
+// Plots the monthly values of several series stacked on each other in a png file. The series
+// are sorted by descending total: beyond maxStackedSeries, the smallest ones are summed as "Others".
+func plotStackedBargraph(plotFileName string, title string, xLabels []string, names []string, values [][]int) error {
+	p, err := newStackedBarGraph(title, xLabels, names, values)
+	if err != nil {
+		return err
+	}
 	return p.Save(10*vg.Inch, 6*vg.Inch, plotFileName)
 }
 
+// Builds the stacked bar graph of the monthly values of several series
+func newStackedBarGraph(title string, xLabels []string, names []string, values [][]int) (*plot.Plot, error) {
+	p := plot.New()
+
+	p.Title.Text = title
+	p.Y.Label.Text = "Count"
+	p.Legend.Top = true
+	p.Legend.Left = true
+
+	names, values = limitStackedSeries(names, values)
+
+	w := vg.Points(20)
+	var previous *plotter.BarChart
+	for i := range values {
+		bars, err := plotter.NewBarChart(plotter.Values(convertValuesToFloats(values[i])), w)
+		if err != nil {
+			return nil, err
+		}
+		bars.LineStyle.Width = vg.Length(0)
+		bars.Color = plotutil.Color(i)
+		if previous != nil {
+			bars.StackOn(previous)
+		}
+		p.Add(bars)
+		p.Legend.Add(names[i], bars)
+		previous = bars
+	}
+
+	p.NominalX(simplifyAxisLabels(xLabels)...)
+
+	return p, nil
+}
+
+// Sorts the series by descending total and sums the ones beyond maxStackedSeries as "Others"
+func limitStackedSeries(names []string, values [][]int) ([]string, [][]int) {
+	order := make([]int, len(names))
+	totals := make([]int, len(names))
+	for i := range names {
+		order[i] = i
+		for _, value := range values[i] {
+			totals[i] += value
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return totals[order[a]] > totals[order[b]] })
+
+	var sortedNames []string
+	var sortedValues [][]int
+	for rank, i := range order {
+		if rank < maxStackedSeries-1 || len(order) <= maxStackedSeries {
+			sortedNames = append(sortedNames, names[i])
+			sortedValues = append(sortedValues, values[i])
+			continue
+		}
+		if rank == maxStackedSeries-1 {
+			sortedNames = append(sortedNames, "Others")
+			sortedValues = append(sortedValues, make([]int, len(values[i])))
+		}
+		others := sortedValues[len(sortedValues)-1]
+		for month, value := range values[i] {
+			others[month] += value
+		}
+	}
+	return sortedNames, sortedValues
+}
+
 // take the list of months and transforms this to a lighter list that can be displayed on the graph
 func simplifyAxisLabels(inputLabels []string) []string {
 	var outputLabels []string
@@ -115,17 +275,78 @@ func simplifyAxisLabels(inputLabels []string) []string {
 	return outputLabels
 }
 
-// Converts a slice of numerical strings into a slice of floats.
-func convertValuesToInts(stringValues []string) ([]float64, error) {
-	var floatValues []float64
+// Converts a slice of integers into a slice of floats (as expected by the plotter).
+func convertValuesToFloats(values []int) []float64 {
+	floatValues := make([]float64, len(values))
 
-	for _, stringValue := range stringValues {
-		value, err := strconv.ParseFloat(strings.TrimSpace(stringValue), 64)
-		if err != nil {
-			return nil, fmt.Errorf("Unexpected error converting data to int (%v)", err)
-		} else {
-			floatValues = append(floatValues, value)
+	for i, value := range values {
+		floatValues[i] = float64(value)
+	}
+	return floatValues
+}
+
+// Plots the trend of the community health metrics: the number of active users and the bus factors
+// on top, the concentration indices below. The image format is deduced from the file extension.
+func plotMetricsTrend(plotFileName string, series []pivot.Metrics) error {
+	labels := make([]string, len(series))
+	for i, metrics := range series {
+		labels[i] = pivot.FormatMonth(metrics.Month)
+	}
+	simplifiedLabels := simplifyAxisLabels(labels)
+
+	metricLine := func(value func(pivot.Metrics) float64) plotter.XYs {
+		points := make(plotter.XYs, len(series))
+		for i, metrics := range series {
+			points[i].X = float64(i)
+			points[i].Y = value(metrics)
 		}
+		return points
+	}
+
+	users := plot.New()
+	users.Title.Text = "Active contributors and bus factors"
+	users.Y.Label.Text = "Users"
+	users.Y.Min = 0
+	err := plotutil.AddLines(users,
+		"Active contributors", metricLine(func(m pivot.Metrics) float64 { return float64(m.ActiveUsers) }),
+		"Bus factor (50%)", metricLine(func(m pivot.Metrics) float64 { return float64(m.BusFactor50) }),
+		"Bus factor (80%)", metricLine(func(m pivot.Metrics) float64 { return float64(m.BusFactor80) }))
+	if err != nil {
+		return err
+	}
+	users.Legend.Top = true
+	users.NominalX(simplifiedLabels...)
+
+	concentration := plot.New()
+	concentration.Title.Text = "Concentration of the contributions"
+	concentration.Y.Label.Text = "Index"
+	concentration.Y.Min = 0
+	concentration.Y.Max = 1
+	err = plotutil.AddLines(concentration,
+		"Gini coefficient", metricLine(func(m pivot.Metrics) float64 { return m.Gini }),
+		"Herfindahl index", metricLine(func(m pivot.Metrics) float64 { return m.Herfindahl }))
+	if err != nil {
+		return err
+	}
+	concentration.Legend.Top = true
+	concentration.NominalX(simplifiedLabels...)
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(plotFileName)), ".")
+	img, err := draw.NewFormattedCanvas(10*vg.Inch, 10*vg.Inch, format)
+	if err != nil {
+		return err
+	}
+	plots := [][]*plot.Plot{{users}, {concentration}}
+	canvases := plot.Align(plots, draw.Tiles{Rows: 2, Cols: 1, PadY: vg.Points(20)}, draw.New(img))
+	for row := range plots {
+		plots[row][0].Draw(canvases[row][0])
+	}
+
+	f, err := os.Create(plotFileName)
+	if err != nil {
+		return err
 	}
-	return floatValues, nil
+	defer f.Close()
+	_, err = img.WriteTo(f)
+	return err
 }
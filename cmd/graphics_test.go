@@ -40,11 +40,11 @@ func Test_plot_bargraph(t *testing.T) {
 		"2023-01", "2023-02", "2023-03", "2023-04", "2023-05", "2023-06", "2023-07", "2023-08", "2023-09", "2023-10", "2023-11", "2023-12",
 		"2024-01", "2024-02", "2024-03", "2024-04"}
 
-	values := []string{"43", "39", "42", "48", "31", "36", "31", "32", "38", "53", "51", "35",
-		"43", "64", "58", "45", "28", "17", "37", "38", "54", "76", "89", "43",
-		"52", "48", "104", "99", "43", "19", "34", "76", "61", "39", "172", "91",
-		"147", "54", "43", "65", "59", "126", "136", "171", "85", "113", "81", "143",
-		"76", "22", "44", "31"}
+	values := []int{43, 39, 42, 48, 31, 36, 31, 32, 38, 53, 51, 35,
+		43, 64, 58, 45, 28, 17, 37, 38, 54, 76, 89, 43,
+		52, 48, 104, 99, 43, 19, 34, 76, 61, 39, 172, 91,
+		147, 54, 43, 65, 59, 126, 136, 171, 85, 113, 81, 143,
+		76, 22, 44, 31}
 
 	err := plot_bargraph(tempDir, "test", InputTypeSubmitters, labels, values)
 	assert.NoError(t, err, "Function should not have failed")
@@ -79,50 +79,65 @@ func Test_generateAxisLabels(t *testing.T) {
 
 func Test_convertValuesToFloats(t *testing.T) {
 	type args struct {
-		stringValues []string
+		values []int
 	}
 	tests := []struct {
-		name    string
-		args    args
-		want    []float64
-		wantErr bool
+		name string
+		args args
+		want []float64
 	}{
 		{
 			"Happy case",
-			args{stringValues: []string{"1", "2", "3"}},
+			args{values: []int{1, 2, 3}},
 			[]float64{1, 2, 3},
-			false,
-		},
-		//TODO: empty input
-		{
-			"empty element",
-			args{stringValues: []string{"1", "2", ""}},
-			nil,
-			true,
-		},
-		{
-			"space element",
-			args{stringValues: []string{"1", "2", " "}},
-			nil,
-			true,
 		},
 		{
-			"invalid value",
-			args{stringValues: []string{"1", "2", "junk"}},
-			nil,
-			true,
+			"empty input",
+			args{values: []int{}},
+			[]float64{},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := convertValuesToInts(tt.args.stringValues)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("convertValuesToInts() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("convertValuesToInts() = %v, want %v", got, tt.want)
+			if got := convertValuesToFloats(tt.args.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("convertValuesToFloats() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_plotStackedBargraph(t *testing.T) {
+	outputPngFileName := filepath.Join(t.TempDir(), "organization.png")
+
+	err := plotStackedBargraph(outputPngFileName, "Submissions by organization", []string{"2023-01", "2023-02", "2023-03"},
+		[]string{"alpha", "bravo"}, [][]int{{1, 2, 3}, {4, 0, 6}})
+
+	assert.NoError(t, err, "Function should not have failed")
+	assert.FileExists(t, outputPngFileName, "No graphic file generated")
+}
+
+func Test_limitStackedSeries(t *testing.T) {
+	var names []string
+	var values [][]int
+	for i := 1; i <= maxStackedSeries+2; i++ {
+		names = append(names, fmt.Sprintf("user%d", i))
+		values = append(values, []int{i, 1})
+	}
+
+	limitedNames, limitedValues := limitStackedSeries(names, values)
+
+	// Sorted by descending total, the three smallest series are summed
+	assert.Len(t, limitedNames, maxStackedSeries)
+	assert.Equal(t, "user10", limitedNames[0])
+	assert.Equal(t, "Others", limitedNames[maxStackedSeries-1])
+	assert.Equal(t, []int{6, 3}, limitedValues[maxStackedSeries-1])
+}
+
+func Test_plotBaseName(t *testing.T) {
+	assert.Equal(t, "basil", plotBaseName("basil"))
+	assert.Equal(t, "basil", plotBaseName("basil (new)"))
+	assert.Equal(t, "Linux Foundation", plotBaseName("Linux Foundation (churned)"))
+	assert.Equal(t, "AT_T", plotBaseName("AT/T"))
+	assert.Equal(t, "Contributor 3", plotBaseName("Contributor #3 (new)"))
+	assert.Equal(t, "Contributor #3", plotTitleName("Contributor #3 (new)"))
+}
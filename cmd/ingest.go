@@ -0,0 +1,148 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+	"github.com/spf13/cobra"
+)
+
+var ingestedOutputFileName string
+var ingestFormat string
+var ingestTimezone string
+var isVerboseIngest bool
+
+// ingestCmd represents the ingest command
+var ingestCmd = &cobra.Command{
+	Use:   "ingest [event files]",
+	Short: "Builds a pivot table from raw PR or comment events",
+	Long: `The INGEST command builds the pivot table from the raw events exported by the
+upstream scripts (one line per PR or per comment), without requiring GNU datamash.
+
+The events are read from CSV files (with a "user,repo,created_at" header) or
+JSON Lines files (one {"user": ..., "repo": ..., "created_at": ...} object per line).
+The format is deduced from the file extension (".jsonl" or ".ndjson" for JSON Lines)
+unless specified with "--format". The dates are expected in RFC 3339 format.
+
+The events are counted per user and per month. The month of an event is computed
+in UTC unless another time zone (IANA name like "Europe/Brussels") is specified.
+The generated pivot table can be processed by the other commands. With "--out=-",
+it is written to the standard output (the progress messages are written to the
+standard error).`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfiguration(cmd); err != nil {
+			return err
+		}
+		if err := cobra.MinimumNArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		for _, fileName := range args {
+			if !isFileValid(fileName) {
+				return fmt.Errorf("Invalid input file (%s)\n", fileName)
+			}
+		}
+		switch strings.ToLower(ingestFormat) {
+		case "auto", pivot.EventFormatCSV, pivot.EventFormatJSONL:
+		default:
+			return fmt.Errorf("%s is an invalid event format\n", ingestFormat)
+		}
+		if _, err := time.LoadLocation(ingestTimezone); err != nil {
+			return fmt.Errorf("%s is an invalid time zone\n", ingestTimezone)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		standardOutput = cmd.OutOrStdout()
+		location, _ := time.LoadLocation(ingestTimezone)
+
+		var events []pivot.Event
+		for _, fileName := range args {
+			fileEvents, err := readEventFile(fileName, eventFormat(fileName, ingestFormat))
+			if err != nil {
+				return err
+			}
+			if isVerboseIngest {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Read %d events from \"%s\"\n", len(fileEvents), fileName)
+			}
+			events = append(events, fileEvents...)
+		}
+
+		table, err := pivot.FromEvents(events, location)
+		if err != nil {
+			return err
+		}
+		if err := checkTable(table); err != nil {
+			return fmt.Errorf("The generated pivot table can't be processed. %v", err)
+		}
+
+		if err := writePivotTable(ingestedOutputFileName, table); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Aggregated %d events (%d users, %s to %s) into %s\n", len(events), table.NbrOfUsers(),
+			pivot.FormatMonth(table.Month(0)), pivot.FormatMonth(table.Month(table.NbrOfMonths()-1)), describeOutputFile(ingestedOutputFileName))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(ingestCmd)
+
+	ingestCmd.PersistentFlags().StringVarP(&ingestedOutputFileName, "out", "o", "overview.csv", "Output file name of the generated pivot table (\"-\" for the standard output).")
+	ingestCmd.PersistentFlags().StringVarP(&ingestFormat, "format", "f", "auto", "Format of the event files. Can be \"auto\", \"csv\" or \"jsonl\"")
+	ingestCmd.PersistentFlags().StringVarP(&ingestTimezone, "timezone", "", "UTC", "Time zone used to compute the month of the events (IANA name)")
+	ingestCmd.PersistentFlags().BoolVarP(&isVerboseIngest, "verbose", "v", false, "Displays useful info during the aggregation")
+}
+
+// Returns the format of an event file, deducing it from the extension if requested
+func eventFormat(fileName string, requestedFormat string) string {
+	requestedFormat = strings.ToLower(requestedFormat)
+	if requestedFormat != "auto" {
+		return requestedFormat
+	}
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".jsonl", ".ndjson":
+		return pivot.EventFormatJSONL
+	default:
+		return pivot.EventFormatCSV
+	}
+}
+
+// Reads all the events of a file
+func readEventFile(fileName string, format string) ([]pivot.Event, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	events, err := pivot.ReadEvents(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+	return events, nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExecuteIngest_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := tempDir + "/overview.csv"
+	goldenFilename, err := duplicateFile("../test_data/ingested_overview_reference.csv", tempDir)
+
+	assert.NoError(t, err, "Unexpected Golden File duplication error")
+	assert.NotEmpty(t, goldenFilename, "Failure to duplicate Golden File")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"ingest", "../test_data/events_pull_requests.csv", "../test_data/events_pull_requests.jsonl", "--out=" + testOutputFilename})
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.NoError(t, isFileEquivalent(testOutputFilename, goldenFilename))
+
+	// The generated table must be usable as input
+	_, err = loadInputPivotTable(testOutputFilename)
+	assert.NoError(t, err)
+}
+
+func Test_ExecuteIngestWithInvalidTimezone_mustFail(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"ingest", "../test_data/events_pull_requests.csv", "--timezone=Mars/Olympus"})
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	assert.Error(t, error, "Function call should have failed")
+	assert.Contains(t, actual.String(), "Error: Mars/Olympus is an invalid time zone")
+
+	// Reset the flag for the other tests
+	ingestTimezone = "UTC"
+}
+
+func Test_eventFormat(t *testing.T) {
+	assert.Equal(t, "jsonl", eventFormat("events.JSONL", "auto"))
+	assert.Equal(t, "jsonl", eventFormat("events.ndjson", "auto"))
+	assert.Equal(t, "csv", eventFormat("events.csv", "auto"))
+	assert.Equal(t, "csv", eventFormat("events.txt", "auto"))
+	assert.Equal(t, "jsonl", eventFormat("events.csv", "JSONL"))
+}
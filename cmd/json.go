@@ -0,0 +1,217 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+)
+
+// Returns the current time (replaced in the tests to get a reproducible generation time)
+var now = time.Now
+
+// JSON representation of an extraction (EXTRACT or COMPARE command)
+type jsonOutput struct {
+	Metadata jsonMetadata `json:"metadata"`
+	Entries  []jsonEntry  `json:"entries"`
+}
+
+// Parameters and context of the extraction
+type jsonMetadata struct {
+	Command     string `json:"command"`
+	InputFile   string `json:"inputFile"`
+	Type        string `json:"type"`
+	Period      int    `json:"period"`
+	StartMonth  string `json:"startMonth"`
+	EndMonth    string `json:"endMonth"`
+	TopSize     int    `json:"topSize"`
+	CompareWith int    `json:"compareWith,omitempty"`
+	// Input file of the commenters (combined extraction only)
+	CommentersFile   string   `json:"commentersFile,omitempty"`
+	SubmittersWeight *float64 `json:"submittersWeight,omitempty"`
+	CommentersWeight *float64 `json:"commentersWeight,omitempty"`
+	// Activity of all the users over the period (with "--ranking")
+	ActiveUsers        *int `json:"activeUsers,omitempty"`
+	TotalContributions *int `json:"totalContributions,omitempty"`
+	// Filters of the contributions (long-format input with "--repo" and "--label")
+	Repos  []string `json:"repos,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+	// Months of the embedded history arrays
+	HistoryMonths    []string  `json:"historyMonths,omitempty"`
+	ExcludedAccounts []string  `json:"excludedAccounts,omitempty"`
+	GeneratedAt      time.Time `json:"generatedAt"`
+}
+
+// A ranked user of the extraction. Churned users (COMPARE) have no rank.
+type jsonEntry struct {
+	Rank       int      `json:"rank,omitempty"`
+	User       string   `json:"user"`
+	Total      int      `json:"total"`
+	Status     string   `json:"status,omitempty"`
+	Share      *float64 `json:"share,omitempty"`
+	Cumulative *float64 `json:"cumulativeShare,omitempty"`
+	// Movement since the compared period (COMPARE with "--movement")
+	PreviousRank  *int     `json:"previousRank,omitempty"`
+	RankChange    string   `json:"rankChange,omitempty"`
+	PreviousTotal *int     `json:"previousTotal,omitempty"`
+	Change        *int     `json:"change,omitempty"`
+	ChangePercent *float64 `json:"changePercent,omitempty"`
+	Score         *float64 `json:"score,omitempty"`
+	Submissions   *int     `json:"submissions,omitempty"`
+	Comments      *int     `json:"comments,omitempty"`
+	// Contributions per repository over the period (long-format input with a repository column)
+	Repositories []jsonRepository `json:"repositories,omitempty"`
+	History      []int            `json:"history,omitempty"`
+}
+
+// The contributions of a user to a repository
+type jsonRepository struct {
+	Repository string `json:"repository"`
+	Total      int    `json:"total"`
+}
+
+// Builds the JSON document of an EXTRACT ("compareWith" is 0) or COMPARE extraction.
+// The history of the users is embedded if requested.
+func newJSONOutput(table *pivot.PivotTable, extracted *extraction, compareWith int, isHistory bool) jsonOutput {
+	command := "extract"
+	if extracted.isCompare {
+		command = "compare"
+	}
+	document := jsonOutput{
+		Metadata: jsonMetadata{
+			Command:          command,
+			InputFile:        table.Source,
+			Type:             extracted.kind.Name,
+			Period:           period,
+			EndMonth:         extracted.endMonth,
+			TopSize:          topSize,
+			CompareWith:      compareWith,
+			Repos:            repoPatterns,
+			Labels:           labelPatterns,
+			ExcludedAccounts: excludedAccounts(),
+			GeneratedAt:      now().UTC().Truncate(time.Second),
+		},
+		Entries: []jsonEntry{},
+	}
+	// The end month is available: the boundaries are computed again silently
+	if _, _, startMonth, _, err := getBoundaries(table, extracted.endMonth, period, 0); err == nil {
+		document.Metadata.StartMonth = startMonth
+	}
+	if isHistory {
+		document.Metadata.HistoryMonths = table.MonthLabels()
+	}
+	if extracted.summary != nil {
+		document.Metadata.ActiveUsers = &extracted.summary.ActiveUsers
+		document.Metadata.TotalContributions = &extracted.summary.Total
+	}
+
+	var breakdown map[string][]pivot.RepoTotal
+	if len(extracted.users) > 0 {
+		breakdown, _ = repoBreakdown(table, extracted.kind, extracted.endMonth)
+	}
+
+	cumulative := extracted.cumulativeTotals()
+	for i, u := range extracted.users {
+		entry := jsonEntry{Rank: u.rank, User: u.user, Total: u.total, Status: u.status}
+		for _, total := range breakdown[strings.ToLower(entry.User)] {
+			entry.Repositories = append(entry.Repositories, jsonRepository{Repository: total.Repo, Total: total.Total})
+		}
+		if extracted.summary != nil {
+			share := percentage(u.total, extracted.summary.Total)
+			cumulativeShare := percentage(cumulative[i], extracted.summary.Total)
+			entry.Share = &share
+			entry.Cumulative = &cumulativeShare
+		}
+		if extracted.isMovement {
+			addJSONMovement(&entry, u)
+		}
+
+		if isHistory {
+			if index := table.UserIndex(entry.User); index != -1 {
+				entry.History = table.Row(index)
+			}
+		}
+		document.Entries = append(document.Entries, entry)
+	}
+	return document
+}
+
+// Builds the JSON document of a combined (submitters and commenters) extraction
+func newCombinedJSONOutput(submitters *pivot.PivotTable, commenters *pivot.PivotTable, real_endDate string, scores []pivot.Score) jsonOutput {
+	document := newJSONOutput(submitters, &extraction{kind: InputTypeSubmitters, endMonth: real_endDate}, 0, false)
+	document.Metadata.Type = "contributors"
+	document.Metadata.CommentersFile = commenters.Source
+	document.Metadata.SubmittersWeight = &submittersWeight
+	document.Metadata.CommentersWeight = &commentersWeight
+
+	values := make([]float64, len(scores))
+	for i, score := range scores {
+		values[i] = roundScore(score.Score)
+	}
+	ranks := computeRanks(values)
+
+	for i, score := range scores {
+		value, submissions, comments := values[i], score.Totals[0], score.Totals[1]
+		document.Entries = append(document.Entries, jsonEntry{
+			Rank:        ranks[i],
+			User:        score.User,
+			Total:       submissions + comments,
+			Score:       &value,
+			Submissions: &submissions,
+			Comments:    &comments,
+		})
+	}
+	return document
+}
+
+// Adds the movement of a COMPARE user (see addMovement) to the entry
+func addJSONMovement(entry *jsonEntry, u extractedUser) {
+	if u.previousRank != 0 {
+		previousRank := u.previousRank
+		entry.PreviousRank = &previousRank
+	}
+	entry.RankChange = u.rankChange()
+	previousTotal, change := u.previousTotal, u.total-u.previousTotal
+	entry.PreviousTotal = &previousTotal
+	entry.Change = &change
+	if changePercent, found := u.changePercent(); found {
+		changePercent = math.Round(changePercent*100) / 100
+		entry.ChangePercent = &changePercent
+	}
+}
+
+// Writes the JSON document to a file (or to the standard output if its name is "-")
+func writeJSONOutput(outputFileName string, document any) error {
+	out, err := createOutputFile(outputFileName)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
@@ -0,0 +1,150 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+	"github.com/stretchr/testify/assert"
+)
+
+// Executes the command and decodes the generated JSON document
+func executeToJSON(t *testing.T, args []string, outputFileName string) jsonOutput {
+	now = func() time.Time { return time.Date(2023, 5, 2, 10, 30, 0, 0, time.UTC) }
+	defer func() { now = time.Now; argOutputFormat = "auto"; isOutputHistory = false }()
+
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs(args)
+
+	assert.NoError(t, rootCmd.Execute(), "Unexpected failure")
+
+	data, err := os.ReadFile(outputFileName)
+	assert.NoError(t, err, "Output file not generated")
+
+	var document jsonOutput
+	assert.NoError(t, json.Unmarshal(data, &document), "Invalid JSON document")
+	return document
+}
+
+func Test_ExecuteSubmittersExtractToJSON_integrationTest(t *testing.T) {
+	testOutputFilename := filepath.Join(t.TempDir(), "top-submitters.json")
+
+	document := executeToJSON(t, []string{"extract", "../test_data/overview.csv", "--month=2023-04", "--period=12", "--topSize=10", "--type=submitters", "--history", "--out=" + testOutputFilename}, testOutputFilename)
+
+	assert.Equal(t, "extract", document.Metadata.Command)
+	assert.Equal(t, "../test_data/overview.csv", document.Metadata.InputFile)
+	assert.Equal(t, "submitters", document.Metadata.Type)
+	assert.Equal(t, 12, document.Metadata.Period)
+	assert.Equal(t, "2022-05", document.Metadata.StartMonth)
+	assert.Equal(t, "2023-04", document.Metadata.EndMonth)
+	assert.Equal(t, 10, document.Metadata.TopSize)
+	assert.Equal(t, 0, document.Metadata.CompareWith)
+	assert.Equal(t, time.Date(2023, 5, 2, 10, 30, 0, 0, time.UTC), document.Metadata.GeneratedAt)
+	assert.NotEmpty(t, document.Metadata.HistoryMonths)
+
+	assert.Len(t, document.Entries, 10)
+	first := document.Entries[0]
+	assert.Equal(t, jsonEntry{Rank: 1, User: "basil", Total: 1476, History: first.History}, first)
+	assert.Len(t, first.History, len(document.Metadata.HistoryMonths))
+
+	// The history files are still generated
+	assert.FileExists(t, filepath.Join(filepath.Dir(testOutputFilename), "top_submitters_fullHistory.csv"))
+}
+
+func Test_ExecuteSubmittersCompareToJSON_integrationTest(t *testing.T) {
+	// The format is requested explicitly: the extension is ignored
+	testOutputFilename := filepath.Join(t.TempDir(), "top-submitters.txt")
+
+	document := executeToJSON(t, []string{"compare", "../test_data/overview.csv", "--month=latest", "--period=12", "--topSize=35", "--compare=3", "--type=submitters", "--format=json", "--out=" + testOutputFilename}, testOutputFilename)
+
+	assert.Equal(t, "compare", document.Metadata.Command)
+	assert.Equal(t, "2023-04", document.Metadata.EndMonth)
+	assert.Equal(t, 3, document.Metadata.CompareWith)
+	assert.Empty(t, document.Metadata.HistoryMonths)
+
+	last := document.Entries[len(document.Entries)-1]
+	assert.Equal(t, "jimklimov", last.User)
+	assert.Equal(t, "dropped", last.Status)
+	assert.Equal(t, 0, last.Rank)
+	assert.Nil(t, last.History)
+}
+
+func Test_ExecuteSubmittersCompareToJSONStdout_integrationTest(t *testing.T) {
+	defer func() { argOutputFormat = "auto" }()
+
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"compare", "../test_data/overview.csv", "--month=latest", "--period=12", "--topSize=35", "--compare=3", "--type=submitters", "--format=json", "--out=-"})
+
+	assert.NoError(t, rootCmd.Execute(), "Unexpected failure")
+
+	// The standard output only contains the JSON document
+	var document jsonOutput
+	assert.NoError(t, json.Unmarshal(actual.Bytes(), &document), "Invalid JSON document")
+	assert.Equal(t, "compare", document.Metadata.Command)
+	assert.Equal(t, "basil", document.Entries[0].User)
+}
+
+func Test_ExecuteExtractWithInvalidFormat_mustFail(t *testing.T) {
+	defer func() { argOutputFormat = "auto" }()
+
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/overview.csv", "--format=xml"})
+
+	error := rootCmd.Execute()
+
+	assert.Error(t, error, "Function call should have failed")
+	assert.Contains(t, actual.String(), "xml is an invalid output format")
+}
+
+func Test_newJSONOutput_ranks(t *testing.T) {
+	table := loadTestTable(t, [][]string{
+		{"", "2023-01", "2023-02"},
+		{"alpha", "3", "2"},
+		{"bravo", "4", "1"},
+		{"charly", "1", "1"},
+	})
+	extracted := newExtraction(InputTypeSubmitters, "2023-02", []pivot.Total{
+		{User: "alpha", Total: 5},
+		{User: "bravo", Total: 5},
+		{User: "charly", Total: 2},
+	})
+
+	document := newJSONOutput(table, extracted, 0, false)
+
+	assert.Equal(t, []jsonEntry{
+		{Rank: 1, User: "alpha", Total: 5},
+		{Rank: 1, User: "bravo", Total: 5},
+		{Rank: 3, User: "charly", Total: 2},
+	}, document.Entries)
+}
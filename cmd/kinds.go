@@ -0,0 +1,225 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+	"gopkg.in/yaml.v3"
+)
+
+// InputType is the kind of contribution counted in a pivot table (nil if unknown)
+type InputType = *ContributionKind
+
+// ContributionKind describes a kind of contribution (PRs, comments, reviews, ...) and
+// how it is labelled in the generated files.
+type ContributionKind struct {
+	// Name is the value of the "--type" flag (and is used in the history file names)
+	Name string `yaml:"name"`
+	// Label is the title of the user column (e.g. "Submitter")
+	Label string `yaml:"label"`
+	// TotalColumn is the title of the total column (e.g. "Total_PRs")
+	TotalColumn string `yaml:"totalColumn"`
+	// CompareTotalColumn and CompareStatusColumn are the titles of the COMPARE output columns
+	CompareTotalColumn  string `yaml:"compareTotalColumn"`
+	CompareStatusColumn string `yaml:"compareStatusColumn"`
+	// PlotDirectory is the directory of the history plots (relative to the history file)
+	PlotDirectory string `yaml:"plotDirectory"`
+	// PlotTitle prefixes the user name in the title of the history plots (e.g. "Submissions by")
+	PlotTitle string `yaml:"plotTitle"`
+	// Title is the title of the Markdown output (e.g. "Top Submitters")
+	Title string `yaml:"title"`
+	// Introduction is the text template introducing the Markdown output.
+	// It can use {{.TopSize}}, {{.Period}}, {{.EndMonth}} and {{.Name}}.
+	Introduction string `yaml:"introduction"`
+
+	introduction *template.Template
+	// members returns the contributions of the members of an organization (kinds grouped by affiliation only)
+	members func(organization string) (*pivot.PivotTable, error)
+}
+
+// The built-in contribution kinds
+var (
+	InputTypeSubmitters = &ContributionKind{
+		Name:                "submitters",
+		Label:               "Submitter",
+		TotalColumn:         "Total_PRs",
+		CompareTotalColumn:  "Total_PRs",
+		CompareStatusColumn: "Status",
+		PlotDirectory:       "plot",
+		PlotTitle:           "Submissions by",
+		Title:               "Top Submitters",
+		Introduction:        "Extraction of the {{.TopSize}} top submitters (non-bot PR creators) \nover the {{.Period}} months before \"{{.EndMonth}}\".",
+	}
+	InputTypeCommenters = &ContributionKind{
+		Name:               "commenters",
+		Label:              "Commenter",
+		TotalColumn:        "Total_Comments",
+		CompareTotalColumn: "Comments",
+		//FIXME: Check inconsistant capitalisation of Status
+		CompareStatusColumn: "status",
+		PlotDirectory:       "commentersPlot",
+		PlotTitle:           "Comments by",
+		Title:               "Top Commenters",
+		Introduction:        "Extraction of the {{.TopSize}} top (non-bot) commenters \nover the {{.Period}} months before \"{{.EndMonth}}\".",
+	}
+	InputTypeUnknown InputType = nil
+)
+
+// Registry of the contribution kinds, indexed by name
+var contributionKinds = map[string]*ContributionKind{}
+
+// File defining additional contribution kinds (set from the command line)
+var kindsFileName string
+
+func init() {
+	for _, kind := range []*ContributionKind{InputTypeSubmitters, InputTypeCommenters} {
+		if err := RegisterContributionKind(kind); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterContributionKind adds a kind of contribution to the registry, completing the optional fields.
+// Registering a kind with the name of an existing one updates it, except for the built-in kinds
+// which can't be redefined.
+func RegisterContributionKind(kind *ContributionKind) error {
+	if err := kind.complete(); err != nil {
+		return err
+	}
+
+	if existing, found := contributionKinds[kind.Name]; found {
+		if existing == InputTypeSubmitters || existing == InputTypeCommenters {
+			if existing != kind {
+				return fmt.Errorf("The built-in contribution kind \"%s\" can't be redefined", kind.Name)
+			}
+			return nil
+		}
+		if existing != kind {
+			*existing = *kind
+		}
+		return nil
+	}
+	contributionKinds[kind.Name] = kind
+	return nil
+}
+
+// Validates the kind, completes its optional fields and compiles its introduction
+func (kind *ContributionKind) complete() error {
+	kind.Name = strings.ToLower(strings.TrimSpace(kind.Name))
+	if kind.Name == "" {
+		return fmt.Errorf("A contribution kind must have a name")
+	}
+	if kind.Label == "" || kind.TotalColumn == "" {
+		return fmt.Errorf("The contribution kind \"%s\" must define a label and a totalColumn", kind.Name)
+	}
+	if kind.CompareTotalColumn == "" {
+		kind.CompareTotalColumn = kind.TotalColumn
+	}
+	if kind.CompareStatusColumn == "" {
+		kind.CompareStatusColumn = "Status"
+	}
+	if kind.PlotDirectory == "" {
+		kind.PlotDirectory = kind.Name + "Plot"
+	}
+	if kind.PlotTitle == "" {
+		kind.PlotTitle = "Contributions by"
+	}
+	if kind.Title == "" {
+		kind.Title = "Top " + kind.Label + "s"
+	}
+	if kind.Introduction == "" {
+		kind.Introduction = "Extraction of the {{.TopSize}} top {{.Name}} \nover the {{.Period}} months before \"{{.EndMonth}}\"."
+	}
+
+	introduction, err := template.New(kind.Name).Option("missingkey=error").Parse(kind.Introduction)
+	if err != nil {
+		return fmt.Errorf("Invalid introduction of the contribution kind \"%s\": %v", kind.Name, err)
+	}
+	kind.introduction = introduction
+	return nil
+}
+
+// Returns the registered contribution kind with the given name (InputTypeUnknown if not found)
+func lookupInputType(name string) InputType {
+	return contributionKinds[strings.ToLower(name)]
+}
+
+// Returns the sorted names of the registered contribution kinds
+func contributionKindNames() []string {
+	names := make([]string, 0, len(contributionKinds))
+	for name := range contributionKinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Loads and registers the contribution kinds defined in a YAML file
+func loadContributionKinds(fileName string) error {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("Unable to read the contribution kinds file: %v", err)
+	}
+
+	var definition struct {
+		Kinds []*ContributionKind `yaml:"kinds"`
+	}
+	if err := yaml.Unmarshal(data, &definition); err != nil {
+		return fmt.Errorf("Invalid contribution kinds file %s: %v", fileName, err)
+	}
+	for _, kind := range definition.Kinds {
+		if err := RegisterContributionKind(kind); err != nil {
+			return fmt.Errorf("%s: %v", fileName, err)
+		}
+	}
+	return nil
+}
+
+// Returns the name of what is ranked in the outputs: the users, or their organizations
+func (kind *ContributionKind) rankedName() string {
+	if kind.members != nil {
+		return "organizations"
+	}
+	return kind.Name
+}
+
+// Builds the introduction of the Markdown output
+func (kind *ContributionKind) markdownIntroduction(topSize int, period int, endMonth string) string {
+	var buffer bytes.Buffer
+	data := struct {
+		TopSize  int
+		Period   int
+		EndMonth string
+		Name     string
+	}{topSize, period, endMonth, kind.Name}
+	if err := kind.introduction.Execute(&buffer, data); err != nil {
+		return fmt.Sprintf("Extraction of the %d top %s \nover the %d months before \"%s\".", topSize, kind.Name, period, endMonth)
+	}
+	return buffer.String()
+}
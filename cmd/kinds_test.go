@@ -0,0 +1,115 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RegisterContributionKind(t *testing.T) {
+	kind := &ContributionKind{Name: " Issuers ", Label: "Issue opener", TotalColumn: "Total_Issues"}
+	assert.NoError(t, RegisterContributionKind(kind))
+	defer delete(contributionKinds, "issuers")
+
+	assert.Equal(t, kind, lookupInputType("ISSUERS"))
+	assert.Equal(t, "Total_Issues", kind.CompareTotalColumn)
+	assert.Equal(t, "Status", kind.CompareStatusColumn)
+	assert.Equal(t, "issuersPlot", kind.PlotDirectory)
+	assert.Equal(t, "Top Issue openers", kind.Title)
+	assert.Equal(t, "Extraction of the 10 top issuers \nover the 12 months before \"2023-04\".", kind.markdownIntroduction(10, 12, "2023-04"))
+
+	assert.Error(t, RegisterContributionKind(&ContributionKind{Name: "nolabel"}))
+	assert.Error(t, RegisterContributionKind(&ContributionKind{Name: "badtemplate", Label: "x", TotalColumn: "y", Introduction: "{{.TopSize"}))
+	assert.Nil(t, lookupInputType("unknown"))
+
+	// The built-in kinds can't be redefined
+	assert.Error(t, RegisterContributionKind(&ContributionKind{Name: "Submitters", Label: "Author", TotalColumn: "Total"}))
+	assert.Equal(t, "Submitter", InputTypeSubmitters.Label)
+	assert.NoError(t, RegisterContributionKind(InputTypeCommenters))
+}
+
+func Test_builtinContributionKinds(t *testing.T) {
+	assert.Equal(t, InputTypeSubmitters, lookupInputType("submitters"))
+	assert.Equal(t, InputTypeCommenters, lookupInputType("Commenters"))
+	assert.Equal(t, "Extraction of the 35 top submitters (non-bot PR creators) \nover the 12 months before \"2023-04\".", InputTypeSubmitters.markdownIntroduction(35, 12, "2023-04"))
+}
+
+func Test_ExecuteExtractWithCustomKind_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := filepath.Join(tempDir, "top-reviewers.csv")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/overview.csv", "--kinds=../test_data/kinds.yaml", "--type=reviewers", "--month=latest", "--period=12", "--topSize=3", "--history=true", "--out=" + testOutputFilename})
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Reset the flags for the other tests
+	kindsFileName, isOutputHistory, argInputType = "", false, "submitters"
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	content, err := os.ReadFile(testOutputFilename)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "Reviewer,Total_Reviews\n")
+	assert.FileExists(t, filepath.Join(tempDir, "top_reviewers_fullHistory.csv"))
+	assert.DirExists(t, filepath.Join(tempDir, "reviewersPlot"))
+
+	releasers := lookupInputType("releasers")
+	assert.NotNil(t, releasers, "All the kinds of the file should be registered")
+	assert.Equal(t, "releasersPlot", releasers.PlotDirectory)
+}
+
+func Test_ExecuteCompareWithCustomKindHistory_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := filepath.Join(tempDir, "top-releasers-compare.csv")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"compare", "../test_data/overview.csv", "--kinds=../test_data/kinds.yaml", "--type=releasers", "--month=latest", "--period=12", "--topSize=3", "--compare=3", "--history=true", "--out=" + testOutputFilename})
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Reset the flags for the other tests
+	kindsFileName, isOutputHistory, argInputType = "", false, "submitters"
+	topSize, compareWith = 35, 3
+
+	// Check the results: the history is written with the status column of the kind
+	assert.NoError(t, error, "Unexpected failure")
+	content, err := os.ReadFile(testOutputFilename)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "Release author,Total_Releases,Evolution\n")
+	assert.FileExists(t, filepath.Join(tempDir, "top_releasers_evolution_fullHistory.csv"))
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+	"gopkg.in/yaml.v3"
+)
+
+// A login mapped to a key (canonical login, organization, ...), optionally for a range of months
+// (zero months when not limited). This is the content of the aliases and affiliations files.
+type loginMapping struct {
+	Key   string
+	Login string
+	From  time.Time
+	To    time.Time
+}
+
+// A login in a YAML mapping file: either a plain login or a mapping with its validity
+type loginDefinition struct {
+	Login string `yaml:"login"`
+	From  string `yaml:"from"`
+	To    string `yaml:"to"`
+}
+
+// Accepts both a plain login and a mapping
+func (d *loginDefinition) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		d.Login = node.Value
+		return nil
+	}
+	type plainDefinition loginDefinition
+	return node.Decode((*plainDefinition)(d))
+}
+
+// Reads a mapping file, in YAML (".yaml" or ".yml" extension) or in CSV.
+// The name of the key column is used to detect the (optional) CSV header.
+func readLoginMappingFile(fileName string, keyColumn string) ([]loginMapping, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".yaml", ".yml":
+		return readYAMLLoginMappings(f)
+	default:
+		return readCSVLoginMappings(f, keyColumn)
+	}
+}
+
+// Reads the mappings from a YAML document: the keys are the keys of the document and their
+// logins are given as a list of logins or of mappings ("login", "from" and "to").
+func readYAMLLoginMappings(r io.Reader) ([]loginMapping, error) {
+	definitions := make(map[string][]loginDefinition)
+	if err := yaml.NewDecoder(r).Decode(&definitions); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(definitions))
+	for key := range definitions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var mappings []loginMapping
+	for _, key := range keys {
+		for _, definition := range definitions[key] {
+			mapping, err := newLoginMapping(key, definition.Login, definition.From, definition.To)
+			if err != nil {
+				return nil, err
+			}
+			mappings = append(mappings, mapping)
+		}
+	}
+	return mappings, nil
+}
+
+// Reads the mappings from a CSV file with the columns key, login and, optionally, "from" and "to".
+// The header line (starting with the key column name) is optional and lines starting with "#" are ignored.
+func readCSVLoginMappings(r io.Reader, keyColumn string) ([]loginMapping, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var mappings []loginMapping
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(strings.TrimSpace(record[0]), keyColumn) {
+			continue
+		}
+		if len(record) < 2 || len(record) > 4 {
+			return nil, fmt.Errorf("line %d: expecting the %s, the login and optionally the \"from\" and \"to\" months", i+1, keyColumn)
+		}
+		record = append(record, "", "")
+		mapping, err := newLoginMapping(record[0], record[1], record[2], record[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
+
+// Builds a mapping, parsing its (optional) validity months
+func newLoginMapping(key string, login string, from string, to string) (loginMapping, error) {
+	mapping := loginMapping{Key: strings.TrimSpace(key), Login: strings.TrimSpace(login)}
+	var err error
+	if from = strings.TrimSpace(from); from != "" {
+		if mapping.From, err = pivot.ParseMonth(from); err != nil {
+			return mapping, fmt.Errorf("invalid \"from\" month of \"%s\": %v", mapping.Login, err)
+		}
+	}
+	if to = strings.TrimSpace(to); to != "" {
+		if mapping.To, err = pivot.ParseMonth(to); err != nil {
+			return mapping, fmt.Errorf("invalid \"to\" month of \"%s\": %v", mapping.Login, err)
+		}
+	}
+	return mapping, nil
+}
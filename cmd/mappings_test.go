@@ -0,0 +1,58 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_readYAMLLoginMappings(t *testing.T) {
+	mappings, err := readYAMLLoginMappings(strings.NewReader("basil:\n  - basil-old\n  - login: basil-work\n    from: 2021-01\n    to: 2022-06\nalpha: [alpha2]\n"))
+
+	assert.NoError(t, err)
+	assert.Len(t, mappings, 3)
+	assert.Equal(t, loginMapping{Key: "alpha", Login: "alpha2"}, mappings[0])
+	assert.Equal(t, "basil-work", mappings[2].Login)
+	assert.Equal(t, "2021-01", pivot.FormatMonth(mappings[2].From))
+	assert.Equal(t, "2022-06", pivot.FormatMonth(mappings[2].To))
+
+	_, err = readYAMLLoginMappings(strings.NewReader("basil:\n  - login: basil-work\n    from: 2021\n"))
+	assert.Error(t, err, "An invalid month should be rejected")
+}
+
+func Test_readCSVLoginMappings(t *testing.T) {
+	mappings, err := readCSVLoginMappings(strings.NewReader("canonical,alias,from,to\n# Renamed account\nbasil,basil-old\nbasil, basil-work,2021-01,\n"), "canonical")
+
+	assert.NoError(t, err)
+	assert.Len(t, mappings, 2)
+	assert.Equal(t, loginMapping{Key: "basil", Login: "basil-old"}, mappings[0])
+	assert.Equal(t, "basil-work", mappings[1].Login)
+	assert.Equal(t, "2021-01", pivot.FormatMonth(mappings[1].From))
+	assert.True(t, mappings[1].To.IsZero())
+
+	_, err = readCSVLoginMappings(strings.NewReader("basil\n"), "canonical")
+	assert.Error(t, err, "A line without alias should be rejected")
+}
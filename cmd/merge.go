@@ -0,0 +1,112 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+	"github.com/spf13/cobra"
+)
+
+var mergedOutputFileName string
+var isVerboseMerge bool
+
+// mergeCmd represents the merge command
+var mergeCmd = &cobra.Command{
+	Use:   "merge [input files]",
+	Short: "Merges several pivot tables into a single one",
+	Long: `The MERGE command combines several pivot tables (one per GitHub organization
+or per year for example) into a single pivot table that can be processed by the
+other commands.
+
+The months and the users of the input files are united. The contributions of
+a user for a month defined in several files are summed. Months missing between
+the first and the last one are filled with 0.
+
+A month defined in several files with different values is reported as a
+conflict: it is expected when merging different organizations but suspicious
+when merging different periods of the same organization.
+
+With "--out=-", the merged pivot table is written to the standard output (the
+conflicts and the progress messages are written to the standard error).`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfiguration(cmd); err != nil {
+			return err
+		}
+		if err := cobra.MinimumNArgs(2)(cmd, args); err != nil {
+			return err
+		}
+		if err := validateInputFiles(args); err != nil {
+			return err
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		standardOutput = cmd.OutOrStdout()
+		var tables []*pivot.PivotTable
+		for _, fileName := range args {
+			// The merged table is an input of the other commands: it is not filtered nor anonymized
+			table, err := loadRawPivotTable(fileName)
+			if err != nil {
+				return fmt.Errorf("%s: %v", fileName, err)
+			}
+			if isVerboseMerge {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Loaded \"%s\" (%d users, %s to %s)\n", fileName, table.NbrOfUsers(),
+					pivot.FormatMonth(table.Month(0)), pivot.FormatMonth(table.Month(table.NbrOfMonths()-1)))
+			}
+			tables = append(tables, table)
+		}
+
+		merged, conflicts, err := pivot.Merge(tables...)
+		if err != nil {
+			return err
+		}
+
+		writeMergeConflicts(cmd, conflicts, isVerboseMerge)
+
+		if err := writePivotTable(mergedOutputFileName, merged); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Merged %d pivot tables (%d users, %d months) into %s\n", len(tables), merged.NbrOfUsers(), merged.NbrOfMonths(), describeOutputFile(mergedOutputFileName))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+
+	mergeCmd.PersistentFlags().StringVarP(&mergedOutputFileName, "out", "o", "merged_overview.csv", "Output file name of the merged pivot table (\"-\" for the standard output).")
+	mergeCmd.PersistentFlags().BoolVarP(&isVerboseMerge, "verbose", "v", false, "Displays useful info during the merge (the users in conflict for instance)")
+}
+
+// Reports the months defined in several input files with different values
+func writeMergeConflicts(cmd *cobra.Command, conflicts []pivot.Conflict, isVerbose bool) {
+	out := cmd.ErrOrStderr()
+	for _, conflict := range conflicts {
+		fmt.Fprintf(out, "Warning: %s\n", conflict.String())
+		if isVerbose {
+			fmt.Fprintf(out, "    %s\n", strings.Join(conflict.Users, ", "))
+		}
+	}
+}
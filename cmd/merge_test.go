@@ -0,0 +1,95 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExecuteMerge_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := tempDir + "/merged.csv"
+	goldenFilename, err := duplicateFile("../test_data/merged_overview_reference.csv", tempDir)
+
+	assert.NoError(t, err, "Unexpected Golden File duplication error")
+	assert.NotEmpty(t, goldenFilename, "Failure to duplicate Golden File")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	// The merged table keeps every account: the exclusions are applied when ranking
+	rootCmd.SetArgs([]string{"merge", "../test_data/merge_jenkinsci_overview.csv", "../test_data/merge_jenkins-infra_overview.csv", "--exclude=basil", "--out=" + testOutputFilename})
+	defer func() {
+		excludePatterns = nil
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.NoError(t, isFileEquivalent(testOutputFilename, goldenFilename))
+	assert.Contains(t, actual.String(), "Warning: Month 2023-01 is defined with different values in ../test_data/merge_jenkinsci_overview.csv, ../test_data/merge_jenkins-infra_overview.csv (2 users)")
+	assert.True(t, checkFile(testOutputFilename, true), "The merged file should pass the check")
+}
+
+func Test_ExecuteMergeToStandardOutput_integrationTest(t *testing.T) {
+	golden, err := os.ReadFile("../test_data/merged_overview_reference.csv")
+	assert.NoError(t, err)
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	actualErr := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actualErr)
+	rootCmd.SetArgs([]string{"merge", "../test_data/merge_jenkinsci_overview.csv", "../test_data/merge_jenkins-infra_overview.csv", "--out=-"})
+	defer func() { mergedOutputFileName = "merged_overview.csv" }()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results: the messages are written to the standard error
+	assert.NoError(t, error, "Unexpected failure")
+	assert.Equal(t, string(golden), actual.String())
+	assert.Contains(t, actualErr.String(), "Warning: Month 2023-01 is defined with different values")
+	assert.Contains(t, actualErr.String(), "into the standard output")
+}
+
+func Test_ExecuteMergeSingleFile_mustFail(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"merge", "../test_data/merge_jenkinsci_overview.csv"})
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	assert.Error(t, error, "Function call should have failed")
+	assert.Contains(t, actual.String(), "Error: requires at least 2 arg(s), only received 1")
+}
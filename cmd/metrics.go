@@ -0,0 +1,234 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+	"github.com/spf13/cobra"
+)
+
+var metricsOutputFileName string
+var metricsWindow int
+var metricsPlotFileName string
+
+// metricsCmd represents the metrics command
+var metricsCmd = &cobra.Command{
+	Use:   "metrics [input file]",
+	Short: "Computes community health metrics (bus factor, Gini coefficient, ...) from a pivot table",
+	Long: `The METRICS command computes, for every month, the health indicators of
+the community over a window of "--window" months ending with that month:
+  - the number of active contributors and the total of their contributions,
+  - the "bus factor": the minimum number of users covering 50% and 80% of the
+    contributions,
+  - the Gini coefficient of the contributions (0 when everybody contributes
+    equally, close to 1 when a few users do everything),
+  - the Herfindahl index (the sum of the squared shares of the users).
+
+The metrics are computed for the "--period" months before "--month" (all the
+available months if the period is 0). Months without a complete window are skipped.
+
+The output format is deduced from the extension of the output file (".json" for
+JSON, ".md" for Markdown, CSV otherwise) unless specified with "--format". Use
+"--out=-" to write to the standard output. A trend chart of the metrics can be
+generated with "--plot" (PNG, SVG or PDF, based on the extension).`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfiguration(cmd); err != nil {
+			return err
+		}
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		if !isInputFileValid(args[0]) {
+			return fmt.Errorf("Invalid input file\n")
+		}
+		if !isValidMonth(endMonth, isVerboseExtract) {
+			return fmt.Errorf("\"%s\" is an invalid month\n", endMonth)
+		}
+		if !isValidOutputFormat(argOutputFormat) {
+			return fmt.Errorf("%s is an invalid output format\n", argOutputFormat)
+		}
+		if metricsWindow < 1 {
+			return fmt.Errorf("The window must be at least one month\n")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		standardOutput = cmd.OutOrStdout()
+
+		table, err := loadInputPivotTable(args[0])
+		if err != nil {
+			return err
+		}
+
+		series, err := computeMetricsSeries(table, endMonth, period, metricsWindow)
+		if err != nil {
+			return err
+		}
+
+		if err := writeMetrics(table, series, metricsOutputFileName); err != nil {
+			return err
+		}
+
+		if metricsPlotFileName != "" {
+			if err := CheckDir(metricsPlotFileName); err != nil {
+				return err
+			}
+			if err := plotMetricsTrend(metricsPlotFileName, series); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(metricsCmd)
+
+	metricsCmd.PersistentFlags().StringVarP(&metricsOutputFileName, "out", "o", "metrics.csv", "Output file name (\"-\" for the standard output).")
+	metricsCmd.PersistentFlags().StringVarP(&argOutputFormat, "format", "f", "auto", "Output format. Can be \"auto\" (based on the file extension), \"csv\", \"md\" or \"json\"")
+	metricsCmd.PersistentFlags().IntVarP(&period, "period", "p", 12, "Number of months to compute the metrics for (0 for all the available months).")
+	metricsCmd.PersistentFlags().StringVarP(&endMonth, "month", "m", "latest", "Last month to compute the metrics for.")
+	metricsCmd.PersistentFlags().IntVarP(&metricsWindow, "window", "w", 1, "Number of months accumulated to compute the metrics of a month (rolling window).")
+	metricsCmd.PersistentFlags().StringVarP(&metricsPlotFileName, "plot", "", "", "File name of the trend chart of the metrics (\".png\", \".svg\" or \".pdf\").")
+	metricsCmd.PersistentFlags().BoolVarP(&isVerboseExtract, "verbose", "v", false, "Displays useful info during the computation")
+}
+
+// Computes the metrics of every month of the period, each over a rolling window of months
+func computeMetricsSeries(table *pivot.PivotTable, endMonth string, period int, window int) ([]pivot.Metrics, error) {
+	firstColumn, lastColumn, startMonth, lastMonth, err := getBoundaries(table, endMonth, period, 0)
+	if err != nil {
+		return nil, err
+	}
+	if isVerboseExtract {
+		fmt.Fprintf(os.Stderr, "Computing the metrics of \"%s\" between %s and %s (window of %d months)\n", table.Source, startMonth, lastMonth, window)
+	}
+
+	series := table.RollingMetrics(firstColumn, lastColumn, window)
+	if len(series) == 0 {
+		return nil, fmt.Errorf("No month with a complete window of %d months between %s and %s", window, startMonth, lastMonth)
+	}
+	return series, nil
+}
+
+// Converts the metrics to CSV records (header included)
+func metricsToRecords(series []pivot.Metrics) [][]string {
+	records := [][]string{{"Month", "Window_Start", "Active_Contributors", "Total_Contributions", "Bus_Factor_50", "Bus_Factor_80", "Gini", "Herfindahl"}}
+	for _, metrics := range series {
+		records = append(records, []string{
+			pivot.FormatMonth(metrics.Month),
+			pivot.FormatMonth(metrics.Start),
+			strconv.Itoa(metrics.ActiveUsers),
+			strconv.Itoa(metrics.Total),
+			strconv.Itoa(metrics.BusFactor50),
+			strconv.Itoa(metrics.BusFactor80),
+			formatIndex(metrics.Gini),
+			formatIndex(metrics.Herfindahl),
+		})
+	}
+	return records
+}
+
+// Formats an index (between 0 and 1) with four decimals
+func formatIndex(index float64) string {
+	return strconv.FormatFloat(index, 'f', 4, 64)
+}
+
+// JSON representation of the metrics
+type jsonMetrics struct {
+	Metadata jsonMetricsMetadata `json:"metadata"`
+	Months   []jsonMonthMetrics  `json:"months"`
+}
+
+type jsonMetricsMetadata struct {
+	InputFile        string    `json:"inputFile"`
+	Window           int       `json:"window"`
+	StartMonth       string    `json:"startMonth"`
+	EndMonth         string    `json:"endMonth"`
+	ExcludedAccounts []string  `json:"excludedAccounts,omitempty"`
+	GeneratedAt      time.Time `json:"generatedAt"`
+}
+
+type jsonMonthMetrics struct {
+	Month              string  `json:"month"`
+	WindowStart        string  `json:"windowStart"`
+	ActiveContributors int     `json:"activeContributors"`
+	TotalContributions int     `json:"totalContributions"`
+	BusFactor50        int     `json:"busFactor50"`
+	BusFactor80        int     `json:"busFactor80"`
+	Gini               float64 `json:"gini"`
+	Herfindahl         float64 `json:"herfindahl"`
+}
+
+// Writes the metrics in the requested format (see getOutputFormat)
+func writeMetrics(table *pivot.PivotTable, series []pivot.Metrics, outputFileName string) error {
+	outputFormat := getOutputFormat(outputFileName, argOutputFormat)
+
+	if isVerboseExtract {
+		fmt.Fprintf(os.Stderr, "Writing metrics to %s %s\n\n", describeOutputFile(outputFileName), describeOutputFormat(outputFormat))
+	}
+
+	// Check that the output directory exists
+	if err := CheckDir(outputFileName); err != nil {
+		return err
+	}
+
+	switch outputFormat {
+	case outputFormatMarkdown:
+		introduction := "# Community Health Metrics\n"
+		introduction = introduction + fmt.Sprintf("\nMetrics of \"%s\" computed over a rolling window of %d months.\n", table.Source, metricsWindow)
+		introduction = introduction + excludedAccountsNote() + "\n"
+		writeDataAsMarkdown(outputFileName, metricsToRecords(series), introduction, false, InputTypeSubmitters)
+	case outputFormatJSON:
+		document := jsonMetrics{
+			Metadata: jsonMetricsMetadata{
+				InputFile:        table.Source,
+				Window:           metricsWindow,
+				StartMonth:       pivot.FormatMonth(series[0].Month),
+				EndMonth:         pivot.FormatMonth(series[len(series)-1].Month),
+				ExcludedAccounts: excludedAccounts(),
+				GeneratedAt:      now().UTC().Truncate(time.Second),
+			},
+		}
+		for _, metrics := range series {
+			document.Months = append(document.Months, jsonMonthMetrics{
+				Month:              pivot.FormatMonth(metrics.Month),
+				WindowStart:        pivot.FormatMonth(metrics.Start),
+				ActiveContributors: metrics.ActiveUsers,
+				TotalContributions: metrics.Total,
+				BusFactor50:        metrics.BusFactor50,
+				BusFactor80:        metrics.BusFactor80,
+				Gini:               metrics.Gini,
+				Herfindahl:         metrics.Herfindahl,
+			})
+		}
+		return writeJSONOutput(outputFileName, document)
+	default:
+		writeCSVtoFile(outputFileName, metricsToRecords(series))
+		writeExcludedAccountsCSV(outputFileName)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExecuteMetrics_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := filepath.Join(tempDir, "metrics.csv")
+	testPlotFilename := filepath.Join(tempDir, "metrics.png")
+	goldenFilename, err := duplicateFile("../test_data/metrics_reference.csv", tempDir)
+
+	assert.NoError(t, err, "Unexpected Golden File duplication error")
+	assert.NotEmpty(t, goldenFilename, "Failure to duplicate Golden File")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"metrics", "../test_data/overview.csv", "--month=2023-04", "--period=6", "--window=3", "--plot=" + testPlotFilename, "--out=" + testOutputFilename})
+	defer func() { metricsWindow = 1; metricsPlotFileName = ""; period = 12 }()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.NoError(t, isFileEquivalent(testOutputFilename, goldenFilename))
+	assert.FileExists(t, testPlotFilename)
+}
+
+func Test_ExecuteMetricsToJSON_integrationTest(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"metrics", "../test_data/overview.csv", "--month=2023-04", "--period=2", "--window=1", "--format=json", "--out=-"})
+	defer func() { argOutputFormat = "auto"; period = 12; metricsOutputFileName = "metrics.csv" }()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	var document jsonMetrics
+	assert.NoError(t, json.Unmarshal(actual.Bytes(), &document), "Invalid JSON document")
+	assert.Equal(t, "2023-03", document.Metadata.StartMonth)
+	assert.Equal(t, "2023-04", document.Metadata.EndMonth)
+	assert.Len(t, document.Months, 2)
+	assert.Equal(t, document.Months[1].Month, "2023-04")
+	assert.Positive(t, document.Months[1].BusFactor80)
+}
+
+func Test_ExecuteMetricsWithInvalidWindow_mustFail(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"metrics", "../test_data/overview.csv", "--window=0"})
+	defer func() { metricsWindow = 1 }()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.Error(t, error, "Function call should have failed")
+	assert.Contains(t, actual.String(), "The window must be at least one month")
+}
@@ -0,0 +1,254 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+	"github.com/spf13/cobra"
+)
+
+var newcomersOutputFileName string
+
+// The newcomers period has its own default: it can't share the "period" variable of the other commands
+var newcomersPeriod int
+
+// Number of months after the first contribution used to compute the retention of the newcomers
+var retentionMonths = []int{3, 6, 12}
+
+// newcomersCmd represents the newcomers command
+var newcomersCmd = &cobra.Command{
+	Use:   "newcomers [input file]",
+	Short: "Lists the users whose first contribution is in the given period",
+	Long: `The NEWCOMERS command lists the users whose first contribution (first month
+with a non-zero value in the whole pivot table) is in the "--period" months
+before "--month" (by default, the last month of the pivot table). For every
+newcomer, their activity since their first contribution is given: total number
+of contributions, number of active months and last active month.
+
+The command also reports the retention of the newcomers: the share of the
+newcomers still active 3, 6 and 12 months after their first contribution
+(with at least one contribution in the 3 months ending at that time). Only the
+newcomers whose first contribution is old enough are taken into account.
+
+The first contributions can't be detected in the first month of the pivot
+table: it is ignored.
+
+The output format is deduced from the extension of the output file (".json" for
+JSON, ".md" for Markdown, CSV otherwise) unless specified with "--format".
+The Markdown and JSON outputs contain both the newcomers and the retention. In
+CSV, the retention is written to a second file, suffixed with "_retention" (after
+the newcomers and an empty line when the output is the standard output).`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfiguration(cmd); err != nil {
+			return err
+		}
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		if !isInputFileValid(args[0]) {
+			return fmt.Errorf("Invalid input file\n")
+		}
+		if !isValidMonth(endMonth, isVerboseExtract) {
+			return fmt.Errorf("\"%s\" is an invalid month\n", endMonth)
+		}
+		if newcomersPeriod < 1 {
+			return fmt.Errorf("The period must be at least one month\n")
+		}
+		if !isValidOutputFormat(argOutputFormat) {
+			return fmt.Errorf("%s is an invalid output format\n", argOutputFormat)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		standardOutput = cmd.OutOrStdout()
+
+		table, err := loadInputPivotTable(args[0])
+		if err != nil {
+			return err
+		}
+
+		firstColumn, lastColumn, startMonth, lastMonth, err := getBoundaries(table, endMonth, newcomersPeriod, 0)
+		if err != nil {
+			return err
+		}
+		if firstColumn == 0 {
+			fmt.Fprintf(os.Stderr, "Warning: the first month of the pivot table (%s) is ignored: the first contributions can't be detected in it\n", startMonth)
+		}
+
+		newcomers := table.Newcomers(firstColumn, lastColumn)
+		var retentions []pivot.Retention
+		for _, after := range retentionMonths {
+			retentions = append(retentions, table.Retention(newcomers, after))
+		}
+		if isVerboseExtract {
+			fmt.Fprintf(os.Stderr, "Found %d newcomers in \"%s\" between %s and %s\n", len(newcomers), table.Source, startMonth, lastMonth)
+		}
+
+		return writeNewcomers(table, newcomers, retentions, startMonth, lastMonth, newcomersOutputFileName)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(newcomersCmd)
+
+	newcomersCmd.PersistentFlags().StringVarP(&newcomersOutputFileName, "out", "o", "newcomers.csv", "Output file name (\"-\" for the standard output).")
+	newcomersCmd.PersistentFlags().StringVarP(&argOutputFormat, "format", "f", "auto", "Output format. Can be \"auto\" (based on the file extension), \"csv\", \"md\" or \"json\"")
+	newcomersCmd.PersistentFlags().IntVarP(&newcomersPeriod, "period", "p", 1, "Number of months to look for first contributions.")
+	newcomersCmd.PersistentFlags().StringVarP(&endMonth, "month", "m", "latest", "Last month to look for first contributions.")
+	newcomersCmd.PersistentFlags().BoolVarP(&isVerboseExtract, "verbose", "v", false, "Displays useful info during the computation")
+}
+
+// Converts the newcomers to CSV records (header included)
+func newcomersToRecords(newcomers []pivot.Newcomer) [][]string {
+	records := [][]string{{"User", "First_Month", "Total_Since", "Active_Months", "Last_Active_Month"}}
+	for _, newcomer := range newcomers {
+		records = append(records, []string{
+			newcomer.User,
+			pivot.FormatMonth(newcomer.FirstMonth),
+			strconv.Itoa(newcomer.Total),
+			strconv.Itoa(newcomer.ActiveMonths),
+			pivot.FormatMonth(newcomer.LastMonth),
+		})
+	}
+	return records
+}
+
+// Converts the retention of the newcomers to CSV records (header included)
+func retentionsToRecords(retentions []pivot.Retention) [][]string {
+	records := [][]string{{"After_Months", "Newcomers", "Retained", "Retention_%"}}
+	for _, retention := range retentions {
+		rate := ""
+		if retention.Newcomers > 0 {
+			rate = formatPercentage(retention.Retained, retention.Newcomers)
+		}
+		records = append(records, []string{
+			strconv.Itoa(retention.After),
+			strconv.Itoa(retention.Newcomers),
+			strconv.Itoa(retention.Retained),
+			rate,
+		})
+	}
+	return records
+}
+
+// JSON representation of the newcomers
+type jsonNewcomers struct {
+	Metadata  jsonNewcomersMetadata `json:"metadata"`
+	Newcomers []jsonNewcomer        `json:"newcomers"`
+	Retention []jsonRetention       `json:"retention"`
+}
+
+type jsonNewcomersMetadata struct {
+	InputFile        string    `json:"inputFile"`
+	StartMonth       string    `json:"startMonth"`
+	EndMonth         string    `json:"endMonth"`
+	ExcludedAccounts []string  `json:"excludedAccounts,omitempty"`
+	GeneratedAt      time.Time `json:"generatedAt"`
+}
+
+type jsonNewcomer struct {
+	User            string `json:"user"`
+	FirstMonth      string `json:"firstMonth"`
+	TotalSince      int    `json:"totalSince"`
+	ActiveMonths    int    `json:"activeMonths"`
+	LastActiveMonth string `json:"lastActiveMonth"`
+}
+
+type jsonRetention struct {
+	AfterMonths int `json:"afterMonths"`
+	Newcomers   int `json:"newcomers"`
+	Retained    int `json:"retained"`
+	// Share of the retained newcomers (null if no newcomer is old enough)
+	Rate *float64 `json:"rate"`
+}
+
+// Writes the newcomers and their retention in the requested format (see getOutputFormat)
+func writeNewcomers(table *pivot.PivotTable, newcomers []pivot.Newcomer, retentions []pivot.Retention, startMonth string, lastMonth string, outputFileName string) error {
+	outputFormat := getOutputFormat(outputFileName, argOutputFormat)
+
+	if isVerboseExtract {
+		fmt.Fprintf(os.Stderr, "Writing newcomers to %s %s\n\n", describeOutputFile(outputFileName), describeOutputFormat(outputFormat))
+	}
+
+	// Check that the output directory exists
+	if err := CheckDir(outputFileName); err != nil {
+		return err
+	}
+
+	switch outputFormat {
+	case outputFormatMarkdown:
+		introduction := "# Newcomers\n"
+		introduction = introduction + fmt.Sprintf("\nUsers whose first contribution is between \"%s\" and \"%s\", with their activity since.\n", startMonth, lastMonth)
+		introduction = introduction + excludedAccountsNote()
+		out, err := createOutputFile(outputFileName)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		if len(newcomers) == 0 {
+			fmt.Fprintf(out, "%s\nNo newcomer found.\n", introduction)
+		} else {
+			writeMarkdownTable(out, newcomersToRecords(newcomers), introduction, false, InputTypeSubmitters)
+		}
+		fmt.Fprint(out, "\n")
+		writeMarkdownTable(out, retentionsToRecords(retentions), "## Retention\n\nShare of the newcomers still active some months after their first contribution.\n", false, InputTypeSubmitters)
+	case outputFormatJSON:
+		document := jsonNewcomers{
+			Metadata: jsonNewcomersMetadata{
+				InputFile:        table.Source,
+				StartMonth:       startMonth,
+				EndMonth:         lastMonth,
+				ExcludedAccounts: excludedAccounts(),
+				GeneratedAt:      now().UTC().Truncate(time.Second),
+			},
+			Newcomers: []jsonNewcomer{},
+		}
+		for _, newcomer := range newcomers {
+			document.Newcomers = append(document.Newcomers, jsonNewcomer{
+				User:            newcomer.User,
+				FirstMonth:      pivot.FormatMonth(newcomer.FirstMonth),
+				TotalSince:      newcomer.Total,
+				ActiveMonths:    newcomer.ActiveMonths,
+				LastActiveMonth: pivot.FormatMonth(newcomer.LastMonth),
+			})
+		}
+		for _, retention := range retentions {
+			entry := jsonRetention{AfterMonths: retention.After, Newcomers: retention.Newcomers, Retained: retention.Retained}
+			if retention.Newcomers > 0 {
+				rate := retention.Rate()
+				entry.Rate = &rate
+			}
+			document.Retention = append(document.Retention, entry)
+		}
+		return writeJSONOutput(outputFileName, document)
+	default:
+		writeCSVtoFile(outputFileName, newcomersToRecords(newcomers))
+		writeCompanionCSV(outputFileName, "_retention", retentionsToRecords(retentions))
+		writeExcludedAccountsCSV(outputFileName)
+	}
+	return nil
+}
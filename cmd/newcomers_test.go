@@ -0,0 +1,136 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExecuteNewcomersToMarkdown_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := filepath.Join(tempDir, "newcomers.md")
+	goldenMarkdownFilename, err := duplicateFile("../test_data/newcomers_reference_output.md", tempDir)
+
+	assert.NoError(t, err, "Unexpected Golden File duplication error")
+	assert.NotEmpty(t, goldenMarkdownFilename, "Failure to duplicate Golden File")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"newcomers", "../test_data/overview.csv", "--month=2022-02", "--out=" + testOutputFilename})
+	defer func() { endMonth = "latest" }()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.NoError(t, isFileEquivalent(testOutputFilename, goldenMarkdownFilename))
+}
+
+func Test_ExecuteNewcomersToCSV_integrationTest(t *testing.T) {
+	testOutputFilename := filepath.Join(t.TempDir(), "newcomers.csv")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"newcomers", "../test_data/overview.csv", "--month=2022-02", "--out=" + testOutputFilename})
+	defer func() { endMonth = "latest" }()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results: the retention is written next to the newcomers
+	assert.NoError(t, error, "Unexpected failure")
+	assert.FileExists(t, testOutputFilename)
+	assert.FileExists(t, filepath.Join(filepath.Dir(testOutputFilename), "newcomers_retention.csv"))
+}
+
+func Test_ExecuteNewcomersToStandardOutput_integrationTest(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	actualErr := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actualErr)
+	rootCmd.SetArgs([]string{"newcomers", "../test_data/overview.csv", "--month=2022-02", "--out=-"})
+	defer func() {
+		endMonth = "latest"
+		newcomersOutputFileName = "newcomers.csv"
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results: the retention follows the newcomers on the standard output
+	assert.NoError(t, error, "Unexpected failure")
+	assert.True(t, strings.HasPrefix(actual.String(), "User,First_Month,"))
+	assert.Contains(t, actual.String(), "\n\nAfter_Months,Newcomers,Retained,Retention_%\n")
+}
+
+func Test_ExecuteNewcomersToJSON_integrationTest(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"newcomers", "../test_data/overview.csv", "--month=2022-02", "--period=3", "--format=json", "--out=-"})
+	defer func() {
+		endMonth = "latest"
+		newcomersPeriod = 1
+		argOutputFormat = "auto"
+		newcomersOutputFileName = "newcomers.csv"
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	var document jsonNewcomers
+	assert.NoError(t, json.Unmarshal(actual.Bytes(), &document), "Invalid JSON document")
+	assert.Equal(t, "2021-12", document.Metadata.StartMonth)
+	assert.Equal(t, "2022-02", document.Metadata.EndMonth)
+	assert.NotEmpty(t, document.Newcomers)
+	assert.Equal(t, "2021-12", document.Newcomers[0].FirstMonth)
+	assert.Len(t, document.Retention, 3)
+	assert.Equal(t, 12, document.Retention[2].AfterMonths)
+	assert.NotNil(t, document.Retention[2].Rate)
+}
+
+func Test_retentionsToRecords(t *testing.T) {
+	records := retentionsToRecords([]pivot.Retention{{After: 3, Newcomers: 4, Retained: 1}, {After: 6}})
+
+	assert.Equal(t, [][]string{
+		{"After_Months", "Newcomers", "Retained", "Retention_%"},
+		{"3", "4", "1", "25.00"},
+		{"6", "0", "0", ""},
+	}, records)
+}
@@ -0,0 +1,213 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+	"github.com/spf13/cobra"
+)
+
+var reportDirectory string
+
+// Name of the index page of the report
+const reportIndexFileName = "index.md"
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report [submitters pivot table] [commenters pivot table]",
+	Short: "Generates the complete monthly report (extractions, comparisons, histories and plots)",
+	Long: `The REPORT command generates, in a single directory, the complete monthly
+publication for the submitters and the commenters pivot tables:
+  - the top users (as with the EXTRACT command), in Markdown and CSV format,
+  - the comparison with the situation "--compare" months before (as with the
+    COMPARE command), in Markdown and CSV format,
+  - the history of the top users and their activity plots,
+  - an index page ("index.md") linking all these files together.
+
+The directory is created if it doesn't exist. Existing files are overwritten.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfiguration(cmd); err != nil {
+			return err
+		}
+		if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+			return err
+		}
+		if err := validateInputFiles(args); err != nil {
+			return err
+		}
+		if !isValidMonth(endMonth, isVerboseExtract) {
+			return fmt.Errorf("\"%s\" is an invalid month\n", endMonth)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		submitters, err := loadInputPivotTable(args[0])
+		if err != nil {
+			return fmt.Errorf("%s: %v", args[0], err)
+		}
+		commenters, err := loadInputPivotTable(args[1])
+		if err != nil {
+			return fmt.Errorf("%s: %v", args[1], err)
+		}
+
+		if err := os.MkdirAll(reportDirectory, os.ModePerm); err != nil {
+			return fmt.Errorf("Failed to create the report directory: %v", err)
+		}
+
+		var sections []reportSection
+		for _, part := range []struct {
+			table *pivot.PivotTable
+			kind  InputType
+		}{{submitters, InputTypeSubmitters}, {commenters, InputTypeCommenters}} {
+			section, err := writeReportSection(reportDirectory, part.table, part.kind)
+			if err != nil {
+				return err
+			}
+			sections = append(sections, section)
+		}
+
+		indexFileName := filepath.Join(reportDirectory, reportIndexFileName)
+		if err := writeReportIndex(indexFileName, args, sections); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Report written to \"%s\"\n", indexFileName)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	reportCmd.PersistentFlags().StringVarP(&reportDirectory, "out", "o", "report", "Output directory of the report.")
+	reportCmd.PersistentFlags().IntVarP(&topSize, "topSize", "t", 35, "Number of top users to extract.")
+	reportCmd.PersistentFlags().IntVarP(&period, "period", "p", 12, "Number of months to accumulate.")
+	reportCmd.PersistentFlags().IntVarP(&compareWith, "compare", "c", 3, "Number of months back to compare with.")
+	reportCmd.PersistentFlags().StringVarP(&endMonth, "month", "m", "latest", "Month to extract top users.")
+	reportCmd.PersistentFlags().BoolVarP(&isVerboseExtract, "verbose", "v", false, "Displays useful info during the extraction")
+}
+
+// The files generated for a kind of contribution (relative to the report directory)
+type reportSection struct {
+	kind           InputType
+	endMonth       string
+	extraction     string
+	comparison     string
+	history        string
+	compareHistory string
+	plots          []string
+}
+
+// Writes the extraction and the comparison (Markdown and CSV) of a table, with the histories and plots
+func writeReportSection(directory string, table *pivot.PivotTable, kind InputType) (reportSection, error) {
+	section := reportSection{
+		kind:           kind,
+		extraction:     "top-" + kind.Name,
+		comparison:     "top-" + kind.Name + "-compare",
+		history:        filepath.Base(generateHistoryFilename(directory+"/", kind, false)),
+		compareHistory: filepath.Base(generateHistoryFilename(directory+"/", kind, true)),
+	}
+
+	var err error
+	isHistory := true
+	if section.endMonth, err = writeExtraction(table, kind, filepath.Join(directory, section.extraction+".md"), isHistory); err != nil {
+		return section, err
+	}
+	if _, err = writeExtraction(table, kind, filepath.Join(directory, section.extraction+".csv"), !isHistory); err != nil {
+		return section, err
+	}
+	if _, err = writeComparison(table, kind, filepath.Join(directory, section.comparison+".md"), isHistory); err != nil {
+		return section, err
+	}
+	if _, err = writeComparison(table, kind, filepath.Join(directory, section.comparison+".csv"), !isHistory); err != nil {
+		return section, err
+	}
+
+	section.plots, err = generatedPlots(filepath.Join(directory, section.compareHistory), kind.PlotDirectory)
+	return section, err
+}
+
+// Returns the plots of the users of a history file (relative to the report directory). Only the
+// plots generated from the history are listed: the plot directory may contain older plots, of
+// users who are no longer in the top or who have opted out since then.
+func generatedPlots(historyFileName string, plotDirectory string) ([]string, error) {
+	f, err := os.Open(historyFileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var plots []string
+	if len(records) == 0 {
+		return plots, nil
+	}
+	for _, record := range records[1:] {
+		plots = append(plots, plotDirectory+"/"+plotBaseName(record[0])+".png")
+	}
+	sort.Strings(plots)
+	return plots, nil
+}
+
+// Writes the index page of the report, linking all the generated files
+func writeReportIndex(indexFileName string, inputFileNames []string, sections []reportSection) error {
+	f, err := os.Create(indexFileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	out := bufio.NewWriter(f)
+
+	fmt.Fprintf(out, "# Jenkins Contributors Report\n\n")
+	fmt.Fprintf(out, "Activity of the top %d users over the last %d months,\n", topSize, period)
+	fmt.Fprintf(out, "compared to the situation %d months before.\n", compareWith)
+	fmt.Fprintf(out, "Generated from \"%s\".\n", strings.Join(inputFileNames, "\" and \""))
+	fmt.Fprint(out, excludedAccountsNote())
+
+	for _, section := range sections {
+		fmt.Fprintf(out, "\n## %s\n\n", section.kind.Title)
+		fmt.Fprintf(out, "Over the %d months before \"%s\".\n\n", period, section.endMonth)
+		fmt.Fprintf(out, "- [%s](%s.md) ([CSV](%s.csv))\n", section.kind.Title, section.extraction, section.extraction)
+		fmt.Fprintf(out, "- [New and \"churned\" %s](%s.md) ([CSV](%s.csv))\n", section.kind.Name, section.comparison, section.comparison)
+		fmt.Fprintf(out, "- History of the top %s: [CSV](%s), including the \"churned\" ones: [CSV](%s)\n", section.kind.Name, section.history, section.compareHistory)
+
+		if len(section.plots) > 0 {
+			var links []string
+			for _, plot := range section.plots {
+				links = append(links, fmt.Sprintf("[%s](%s)", strings.TrimSuffix(filepath.Base(plot), ".png"), strings.ReplaceAll(plot, " ", "%20")))
+			}
+			fmt.Fprintf(out, "- Activity plots: %s\n", strings.Join(links, ", "))
+		}
+	}
+
+	return out.Flush()
+}
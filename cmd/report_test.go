@@ -0,0 +1,104 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExecuteReport_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	reportDir := filepath.Join(tempDir, "report")
+	goldenIndexFilename, err := duplicateFile("../test_data/report_index_reference.md", tempDir)
+
+	assert.NoError(t, err, "Unexpected Golden File duplication error")
+	assert.NotEmpty(t, goldenIndexFilename, "Failure to duplicate Golden File")
+
+	// A plot left over by a previous run must not be linked in the index
+	assert.NoError(t, os.MkdirAll(filepath.Join(reportDir, "plot"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(reportDir, "plot", "stale user.png"), []byte{}, 0644))
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"report", "../test_data/overview.csv", "../test_data/overview.csv", "--month=latest", "--period=12", "--topSize=5", "--compare=3", "--out=" + reportDir})
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.NoError(t, isFileEquivalent(filepath.Join(reportDir, "index.md"), goldenIndexFilename))
+	for _, fileName := range []string{
+		"top-submitters.md", "top-submitters.csv", "top-submitters-compare.md", "top-submitters-compare.csv",
+		"top_submitters_fullHistory.csv", "top_submitters_evolution_fullHistory.csv", "plot/basil.png",
+		"top-commenters.md", "top-commenters.csv", "top-commenters-compare.md", "top-commenters-compare.csv",
+		"top_commenters_fullHistory.csv", "top_commenters_evolution_fullHistory.csv", "commentersPlot/basil.png",
+	} {
+		assert.FileExists(t, filepath.Join(reportDir, fileName))
+	}
+
+	// Reset the flags for the other tests
+	topSize = 35
+}
+
+func Test_ExecuteReportWithOneTable_mustFail(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"report", "../test_data/overview.csv"})
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	assert.Error(t, error, "Function call should have failed")
+	assert.Contains(t, actual.String(), "Error: accepts 2 arg(s), received 1")
+}
+
+func Test_ExecuteReportWithDifferentEndMonths_integrationTest(t *testing.T) {
+	reportDir := filepath.Join(t.TempDir(), "report")
+
+	// setup the command line: the commenters table ends one month after the submitters one
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"report", "../test_data/ends_2023-03_overview.csv", "../test_data/ends_2023-04_overview.csv", "--month=latest", "--period=2", "--topSize=2", "--compare=1", "--out=" + reportDir})
+	defer func() { topSize, period, compareWith = 35, 12, 3 }()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results: every section shows its own period
+	assert.NoError(t, error, "Unexpected failure")
+	index, err := os.ReadFile(filepath.Join(reportDir, "index.md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(index), "## Top Submitters\n\nOver the 2 months before \"2023-03\".\n")
+	assert.Contains(t, string(index), "## Top Commenters\n\nOver the 2 months before \"2023-04\".\n")
+}
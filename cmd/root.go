@@ -24,6 +24,7 @@ package cmd
 import (
 	"os"
 
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
 	"github.com/spf13/cobra"
 )
 
@@ -58,6 +59,15 @@ func init() {
 	//Disable the Cobra completion options
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.jenkins-contribution-aggregator.yaml)")
+	rootCmd.PersistentFlags().StringVar(&configFileName, "config", "", "config file, YAML or TOML (default is $HOME/.jenkins-contribution-aggregator.yaml or .toml)")
+	rootCmd.PersistentFlags().StringVar(&kindsFileName, "kinds", "", "YAML file defining additional contribution kinds (reviewers, ...)")
+	rootCmd.PersistentFlags().StringVar(&aliasesFileName, "aliases", "", "YAML or CSV file mapping the aliases of the users to their canonical login")
+	rootCmd.PersistentFlags().StringArrayVar(&excludePatterns, "exclude", nil, "Accounts to exclude: logins, globs (\"*-ci\") or regular expressions (\"/^ci-.*$/\")")
+	rootCmd.PersistentFlags().StringVar(&excludeFileName, "exclude-file", "", "File listing the accounts to exclude (one login, glob or regular expression per line)")
+	rootCmd.PersistentFlags().BoolVar(&isExcludingBots, "exclude-bots", false, "Excludes the common bots (dependabot, renovate, github-actions, *-bot, *[bot])")
+	rootCmd.PersistentFlags().BoolVar(&isAnonymizing, "anonymize", false, "Replaces the logins of all the users by pseudonyms in the outputs")
+	rootCmd.PersistentFlags().StringVar(&optOutFileName, "opt-out", "", "File listing the users always replaced by pseudonyms (one login, glob or regular expression per line)")
+	rootCmd.PersistentFlags().StringVar(&pseudonymStyle, "pseudonyms", pivot.PseudonymNumbered, "Style of the pseudonyms: \"numbered\" (\"Contributor #N\") or \"hashed\" (salted hash of the login, requires \"--salt\")")
+	rootCmd.PersistentFlags().StringVar(&pseudonymSalt, "salt", "", "Salt of the hashed pseudonyms (with \"--pseudonyms=hashed\")")
 
 }
@@ -0,0 +1,245 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
+	"github.com/spf13/cobra"
+)
+
+var timelineOutputFileName string
+
+// timelineCmd represents the timeline command
+var timelineCmd = &cobra.Command{
+	Use:   "timeline [input file]",
+	Short: "Tracks the top users of a window sliding month by month",
+	Long: `The TIMELINE command slides the extraction window ("--period" months) month
+by month across the whole pivot table and records, for every month, the top
+users and their rank (as with the EXTRACT command). Months without a complete
+window are skipped. With a period of 0, all the months since the first one are
+accumulated.
+
+The result is a matrix of the users having been at least once in the top (one
+row per user, one column per month, the rank in the cells) and a summary of
+their presence: the number of months in the top, their best rank, the months
+where they entered the top and the months where they left it.
+
+The output format is deduced from the extension of the output file (".json" for
+JSON, ".md" for Markdown, CSV otherwise) unless specified with "--format".
+The Markdown and JSON outputs contain both the matrix and the summary. In CSV,
+the summary is written to a second file, suffixed with "_summary" (after the
+timeline and an empty line when the output is the standard output).`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfiguration(cmd); err != nil {
+			return err
+		}
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		if !isInputFileValid(args[0]) {
+			return fmt.Errorf("Invalid input file\n")
+		}
+		if !isValidOutputFormat(argOutputFormat) {
+			return fmt.Errorf("%s is an invalid output format\n", argOutputFormat)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		standardOutput = cmd.OutOrStdout()
+
+		table, err := loadInputPivotTable(args[0])
+		if err != nil {
+			return err
+		}
+
+		timeline := table.TopTimeline(0, table.NbrOfMonths()-1, period, topSize)
+		if len(timeline.Months) == 0 {
+			return fmt.Errorf("The pivot table doesn't contain a complete window of %d months", period)
+		}
+		if isVerboseExtract {
+			fmt.Fprintf(os.Stderr, "Tracked the top %d users of \"%s\" between %s and %s: %d users were in the top\n", topSize, table.Source,
+				pivot.FormatMonth(timeline.Months[0]), pivot.FormatMonth(timeline.Months[len(timeline.Months)-1]), len(timeline.Users))
+		}
+
+		return writeTimeline(table, timeline, timelineOutputFileName)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(timelineCmd)
+
+	timelineCmd.PersistentFlags().StringVarP(&timelineOutputFileName, "out", "o", "timeline.csv", "Output file name (\"-\" for the standard output).")
+	timelineCmd.PersistentFlags().StringVarP(&argOutputFormat, "format", "f", "auto", "Output format. Can be \"auto\" (based on the file extension), \"csv\", \"md\" or \"json\"")
+	timelineCmd.PersistentFlags().IntVarP(&topSize, "topSize", "t", 35, "Number of top users to track.")
+	timelineCmd.PersistentFlags().IntVarP(&period, "period", "p", 12, "Number of months of the sliding window (0 to accumulate all the months).")
+	timelineCmd.PersistentFlags().BoolVarP(&isVerboseExtract, "verbose", "v", false, "Displays useful info during the computation")
+}
+
+// Converts the timeline to CSV records (header included): the rank of every user for every month
+func timelineToRecords(timeline *pivot.Timeline) [][]string {
+	header := []string{"User"}
+	for _, month := range timeline.Months {
+		header = append(header, pivot.FormatMonth(month))
+	}
+
+	records := [][]string{header}
+	for row, user := range timeline.Users {
+		record := []string{user}
+		for _, rank := range timeline.Ranks[row] {
+			cell := ""
+			if rank > 0 {
+				cell = strconv.Itoa(rank)
+			}
+			record = append(record, cell)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// Converts the presence of the users in the top to CSV records (header included)
+func membershipsToRecords(memberships []pivot.Membership) [][]string {
+	records := [][]string{{"User", "Months_In_Top", "Best_Rank", "Entries", "Exits"}}
+	for _, membership := range memberships {
+		records = append(records, []string{
+			membership.User,
+			strconv.Itoa(membership.MonthsInTop),
+			strconv.Itoa(membership.BestRank),
+			formatMonthList(membership.Entries),
+			formatMonthList(membership.Exits),
+		})
+	}
+	return records
+}
+
+// Formats a list of months separated with spaces
+func formatMonthList(months []time.Time) string {
+	return strings.Join(formatMonths(months), " ")
+}
+
+// Formats the months as "YYYY-MM"
+func formatMonths(months []time.Time) []string {
+	labels := []string{}
+	for _, month := range months {
+		labels = append(labels, pivot.FormatMonth(month))
+	}
+	return labels
+}
+
+// JSON representation of the timeline
+type jsonTimeline struct {
+	Metadata jsonTimelineMetadata `json:"metadata"`
+	Months   []string             `json:"months"`
+	Users    []jsonTimelineUser   `json:"users"`
+}
+
+type jsonTimelineMetadata struct {
+	InputFile        string    `json:"inputFile"`
+	TopSize          int       `json:"topSize"`
+	Period           int       `json:"period"`
+	ExcludedAccounts []string  `json:"excludedAccounts,omitempty"`
+	GeneratedAt      time.Time `json:"generatedAt"`
+}
+
+type jsonTimelineUser struct {
+	User string `json:"user"`
+	// Rank of the user for every month (null when not in the top)
+	Ranks       []*int   `json:"ranks"`
+	MonthsInTop int      `json:"monthsInTop"`
+	BestRank    int      `json:"bestRank"`
+	Entries     []string `json:"entries"`
+	Exits       []string `json:"exits"`
+}
+
+// Writes the timeline in the requested format (see getOutputFormat)
+func writeTimeline(table *pivot.PivotTable, timeline *pivot.Timeline, outputFileName string) error {
+	outputFormat := getOutputFormat(outputFileName, argOutputFormat)
+
+	if isVerboseExtract {
+		fmt.Fprintf(os.Stderr, "Writing timeline to %s %s\n\n", describeOutputFile(outputFileName), describeOutputFormat(outputFormat))
+	}
+
+	// Check that the output directory exists
+	if err := CheckDir(outputFileName); err != nil {
+		return err
+	}
+
+	memberships := timeline.Memberships()
+
+	switch outputFormat {
+	case outputFormatMarkdown:
+		introduction := "# Top Users Timeline\n"
+		introduction = introduction + fmt.Sprintf("\nRank of the %d top users over a window of %d months sliding month by month.\n", topSize, period)
+		introduction = introduction + excludedAccountsNote() + "\n"
+		introduction = introduction + "## Ranks\n"
+		out, err := createOutputFile(outputFileName)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		writeMarkdownTable(out, timelineToRecords(timeline), introduction, false, InputTypeSubmitters)
+		fmt.Fprint(out, "\n")
+		writeMarkdownTable(out, membershipsToRecords(memberships), "## Presence in the top\n", false, InputTypeSubmitters)
+	case outputFormatJSON:
+		document := jsonTimeline{
+			Metadata: jsonTimelineMetadata{
+				InputFile:        table.Source,
+				TopSize:          topSize,
+				Period:           period,
+				ExcludedAccounts: excludedAccounts(),
+				GeneratedAt:      now().UTC().Truncate(time.Second),
+			},
+			Months: formatMonths(timeline.Months),
+			Users:  []jsonTimelineUser{},
+		}
+		for row, membership := range memberships {
+			user := jsonTimelineUser{
+				User:        membership.User,
+				MonthsInTop: membership.MonthsInTop,
+				BestRank:    membership.BestRank,
+				Entries:     formatMonths(membership.Entries),
+				Exits:       formatMonths(membership.Exits),
+			}
+			for _, rank := range timeline.Ranks[row] {
+				if rank > 0 {
+					rank := rank
+					user.Ranks = append(user.Ranks, &rank)
+				} else {
+					user.Ranks = append(user.Ranks, nil)
+				}
+			}
+			document.Users = append(document.Users, user)
+		}
+		return writeJSONOutput(outputFileName, document)
+	default:
+		writeCSVtoFile(outputFileName, timelineToRecords(timeline))
+		writeCompanionCSV(outputFileName, "_summary", membershipsToRecords(memberships))
+		writeExcludedAccountsCSV(outputFileName)
+	}
+	return nil
+}
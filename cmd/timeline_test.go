@@ -0,0 +1,117 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExecuteTimelineToMarkdown_integrationTest(t *testing.T) {
+	// Setup test environment
+	tempDir := t.TempDir()
+	testOutputFilename := filepath.Join(tempDir, "timeline.md")
+	goldenMarkdownFilename, err := duplicateFile("../test_data/timeline_reference_output.md", tempDir)
+
+	assert.NoError(t, err, "Unexpected Golden File duplication error")
+	assert.NotEmpty(t, goldenMarkdownFilename, "Failure to duplicate Golden File")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"timeline", "../test_data/overview.csv", "--topSize=3", "--period=12", "--out=" + testOutputFilename})
+	defer func() { topSize = 35 }()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	assert.NoError(t, isFileEquivalent(testOutputFilename, goldenMarkdownFilename))
+}
+
+func Test_ExecuteTimelineToCSV_integrationTest(t *testing.T) {
+	testOutputFilename := filepath.Join(t.TempDir(), "timeline.csv")
+
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"timeline", "../test_data/overview.csv", "--topSize=3", "--period=12", "--out=" + testOutputFilename})
+	defer func() { topSize = 35 }()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results: the summary is written next to the matrix
+	assert.NoError(t, error, "Unexpected failure")
+	assert.FileExists(t, testOutputFilename)
+	assert.FileExists(t, filepath.Join(filepath.Dir(testOutputFilename), "timeline_summary.csv"))
+}
+
+func Test_ExecuteTimelineToStandardOutput_integrationTest(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	actualErr := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actualErr)
+	rootCmd.SetArgs([]string{"timeline", "../test_data/overview.csv", "--topSize=3", "--period=12", "--out=-"})
+	defer func() {
+		topSize = 35
+		timelineOutputFileName = "timeline.csv"
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results: the summary follows the timeline on the standard output
+	assert.NoError(t, error, "Unexpected failure")
+	assert.Contains(t, actual.String(), "\n\nUser,Months_In_Top,Best_Rank,Entries,Exits\n")
+	assert.NotContains(t, actualErr.String(), "Months_In_Top")
+}
+
+func Test_ExecuteTimelineToJSON_integrationTest(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"timeline", "../test_data/overview.csv", "--topSize=3", "--period=12", "--format=json", "--out=-"})
+	defer func() { topSize = 35; argOutputFormat = "auto"; timelineOutputFileName = "timeline.csv" }()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.NoError(t, error, "Unexpected failure")
+	var document jsonTimeline
+	assert.NoError(t, json.Unmarshal(actual.Bytes(), &document), "Invalid JSON document")
+	assert.Equal(t, "2020-12", document.Months[0])
+	assert.Equal(t, "olblak", document.Users[0].User)
+	assert.Equal(t, 1, *document.Users[0].Ranks[0])
+	assert.Len(t, document.Users[0].Ranks, len(document.Months))
+	assert.Equal(t, []string{"2020-12"}, document.Users[0].Entries)
+}
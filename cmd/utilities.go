@@ -26,12 +26,15 @@ import (
 	"bufio"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
 )
 
 // Validates that the input file is a real file (and not a directory)
@@ -43,11 +46,47 @@ func isFileValid(fileName string) bool {
 	return !info.IsDir()
 }
 
+// Validates that the input pivot table is a real file or the standard input ("-")
+func isInputFileValid(fileName string) bool {
+	return fileName == pivot.StdinName || isFileValid(fileName)
+}
+
+// Validates the input pivot tables. The standard input can only be read once.
+func validateInputFiles(fileNames []string) error {
+	isStdinUsed := false
+	for _, fileName := range fileNames {
+		if !isInputFileValid(fileName) {
+			return fmt.Errorf("Invalid input file (%s)\n", fileName)
+		}
+		if fileName == pivot.StdinName {
+			if isStdinUsed {
+				return fmt.Errorf("The standard input (\"-\") can only be used once\n")
+			}
+			isStdinUsed = true
+		}
+	}
+	return nil
+}
+
+// Computes the rank of the (sorted) values. Equal values ("ex aequo") share the same rank,
+// the next value being ranked as if they were distinct ("1224" ranking).
+func computeRanks[T comparable](values []T) []int {
+	ranks := make([]int, len(values))
+	for i, value := range values {
+		if i > 0 && value == values[i-1] {
+			ranks[i] = ranks[i-1]
+		} else {
+			ranks[i] = i + 1
+		}
+	}
+	return ranks
+}
+
 // validates whether  the month parameter has the correct format ("YYYY-MM" or "latest")
 func isValidMonth(month string, isVerbose bool) bool {
 	if month == "" {
 		if isVerbose {
-			fmt.Print("Empty month\n")
+			fmt.Fprint(os.Stderr, "Empty month\n")
 		}
 		return false
 	}
@@ -58,7 +97,7 @@ func isValidMonth(month string, isVerbose bool) bool {
 	regexpMonth := regexp.MustCompile(`20[12][0-9]-(0[1-9]|1[0-2])`)
 	if !regexpMonth.MatchString(month) {
 		if isVerbose {
-			fmt.Printf("Supplied data (%s) is not in a valid month format. Should be \"YYYY-MM\" and later than 2010\n", month)
+			fmt.Fprintf(os.Stderr, "Supplied data (%s) is not in a valid month format. Should be \"YYYY-MM\" and later than 2010\n", month)
 		}
 		return false
 	}
@@ -69,7 +108,7 @@ func isValidMonth(month string, isVerbose bool) bool {
 // Write the string slice to a file formatted as a CSV
 func writeCSVtoFile(outputFileName string, csv_output_slice [][]string) {
 	//Open output file
-	out, err := os.Create(outputFileName)
+	out, err := createOutputFile(outputFileName)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -84,6 +123,61 @@ func writeCSVtoFile(outputFileName string, csv_output_slice [][]string) {
 	csv_out.Flush()
 }
 
+// Writes a second table of a CSV output to a file next to the output file, suffixed with the given suffix.
+// With the standard output, the table follows the first one, after an empty line.
+func writeCompanionCSV(outputFileName string, suffix string, csv_output_slice [][]string) {
+	if outputFileName == standardOutputName {
+		fmt.Fprintln(standardOutput)
+		writeCSVtoFile(outputFileName, csv_output_slice)
+		return
+	}
+	writeCSVtoFile(suffixedFileName(outputFileName, suffix), csv_output_slice)
+}
+
+// Output file name standing for the standard output
+const standardOutputName = "-"
+
+// Destination of the output written to the standard output (the output of the command being executed)
+var standardOutput io.Writer = os.Stdout
+
+// Does nothing when closed: the standard output must remain open
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// Creates the output file or, if its name is "-", returns the standard output
+func createOutputFile(outputFileName string) (io.WriteCloser, error) {
+	if outputFileName == standardOutputName {
+		return nopWriteCloser{standardOutput}, nil
+	}
+	return os.Create(outputFileName)
+}
+
+// Checks that the history can be written: its files are written next to the output file,
+// which the standard output doesn't give a directory for
+func checkHistoryArgs() error {
+	if isOutputHistory && outputFileName == standardOutputName {
+		return fmt.Errorf("The history can't be written with the standard output (\"-\"): specify an output file with \"--out\"\n")
+	}
+	return nil
+}
+
+// Returns the name of a secondary output file, next to the output file, by suffixing its name
+func suffixedFileName(outputFileName string, suffix string) string {
+	extension := filepath.Ext(outputFileName)
+	return strings.TrimSuffix(outputFileName, extension) + suffix + extension
+}
+
+// Describes the output file (for the verbose messages)
+func describeOutputFile(outputFileName string) string {
+	if outputFileName == standardOutputName {
+		return "the standard output"
+	}
+	return "\"" + outputFileName + "\""
+}
+
 // returns true if the file extension is .md.
 // It returns false in other cases, thus assuming a CSV output
 func isWithMDfileExtension(filename string) bool {
@@ -95,17 +189,81 @@ func isWithMDfileExtension(filename string) bool {
 	}
 }
 
+// Output formats of the extractions
+const (
+	outputFormatAuto     = "auto"
+	outputFormatCSV      = "csv"
+	outputFormatMarkdown = "md"
+	outputFormatJSON     = "json"
+)
+
+// Validates the requested output format
+func isValidOutputFormat(format string) bool {
+	switch strings.ToLower(format) {
+	case outputFormatAuto, outputFormatCSV, outputFormatMarkdown, outputFormatJSON:
+		return true
+	default:
+		return false
+	}
+}
+
+// Returns the format of the output file: the requested one or, when "auto", the one
+// matching the file extension (".md" or ".json", CSV otherwise)
+func getOutputFormat(filename string, requestedFormat string) string {
+	requestedFormat = strings.ToLower(requestedFormat)
+	if requestedFormat != outputFormatAuto && requestedFormat != "" {
+		return requestedFormat
+	}
+	if isWithMDfileExtension(filename) {
+		return outputFormatMarkdown
+	}
+	if strings.ToLower(filepath.Ext(filename)) == ".json" {
+		return outputFormatJSON
+	}
+	return outputFormatCSV
+}
+
+// Returns the default output file name for the requested format (CSV if "auto")
+func defaultOutputFileName(prefix string, endMonth string, requestedFormat string) string {
+	extension := ".csv"
+	switch strings.ToLower(requestedFormat) {
+	case outputFormatMarkdown:
+		extension = ".md"
+	case outputFormatJSON:
+		extension = ".json"
+	}
+	return prefix + strings.ToUpper(endMonth) + extension
+}
+
+// Describes the output format (for the verbose messages)
+func describeOutputFormat(format string) string {
+	switch format {
+	case outputFormatMarkdown:
+		return "(Markdown format)"
+	case outputFormatJSON:
+		return "(JSON format)"
+	default:
+		return "(CSV format)"
+	}
+}
+
 // TODO: externalize the header creation
 // TODO: return error
 // Writes the data as Markdown
-func writeDataAsMarkdown(outputFileName string, output_data_slice [][]string, introductionText string, isHistory bool, inputType InputType, ) {
+func writeDataAsMarkdown(outputFileName string, output_data_slice [][]string, introductionText string, isHistory bool, inputType InputType) {
 	//Open output file
-	f, err := os.Create(outputFileName)
+	f, err := createOutputFile(outputFileName)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer f.Close()
-	out := bufio.NewWriter(f)
+
+	writeMarkdownTable(f, output_data_slice, introductionText, isHistory, inputType)
+}
+
+// Writes the data as a Markdown table, preceded by the introduction text (if any)
+func writeMarkdownTable(w io.Writer, output_data_slice [][]string, introductionText string, isHistory bool, inputType InputType) {
+	out := bufio.NewWriter(w)
 
 	width_slice, err := get_columnsWidth(output_data_slice)
 	if err != nil {
@@ -117,14 +275,8 @@ func writeDataAsMarkdown(outputFileName string, output_data_slice [][]string, in
 		fmt.Fprintf(out, "%s\n", introductionText)
 	}
 
-	// set the plot directory name based on the data type (submitters or commenters)
-	plot_dir := ""
-	if inputType == InputTypeCommenters {
-		//FIXME: should be a global constant (used in the graph generation and in MD generation)
-		plot_dir = "commentersPlot"
-	} else {
-		plot_dir = "plot"
-	}
+	// set the plot directory name based on the data type
+	plot_dir := inputType.PlotDirectory
 
 	for lineNumber, dataLine := range output_data_slice {
 		//Are we dealing with the title (and underline) ?
@@ -136,10 +288,9 @@ func writeDataAsMarkdown(outputFileName string, output_data_slice [][]string, in
 		writeBuffer := "|"
 		underlineBuffer := "|"
 		for columnNbr, data := range dataLine {
-			//Check whether the value is numerical (we don't treat the case of float data)
-			_, atoi_err := strconv.Atoi(data)
+			//Check whether the value is numerical (integer or score with decimals)
 			exact_width := 0
-			if atoi_err != nil {
+			if !isNumericCell(data) {
 				//not integer -> left align
 				exact_width = 0 - width_slice[columnNbr]
 			} else {
@@ -161,12 +312,11 @@ func writeDataAsMarkdown(outputFileName string, output_data_slice [][]string, in
 			// isHistory means that the history (and plots) is generated along the MD.
 			//This means that we need to create a link to the plots
 			formattedData := ""
-			if isHistory && (columnNbr == 0) && (lineNumber != 0){
+			if isHistory && (columnNbr == 0) && (lineNumber != 0) {
 				//data contains the user name (eventually enriched)
-				name_element := strings.Split(data, " ")
-				cleanedName := name_element[0]
-			
-				formattedData = fmt.Sprintf(" [%s](%s/%s.png)", data, plot_dir,cleanedName)
+				cleanedName := strings.ReplaceAll(plotBaseName(data), " ", "%20")
+
+				formattedData = fmt.Sprintf(" [%s](%s/%s.png)", data, plot_dir, cleanedName)
 			} else {
 				formattedData = fmt.Sprintf(" %*s", exact_width, data)
 			}
@@ -181,6 +331,16 @@ func writeDataAsMarkdown(outputFileName string, output_data_slice [][]string, in
 	out.Flush()
 }
 
+var decimalRegexp = regexp.MustCompile(`^[+-]?[0-9]+\.[0-9]+$`)
+
+// Tells whether a cell holds a number (to be right aligned)
+func isNumericCell(data string) bool {
+	if _, err := strconv.Atoi(data); err == nil {
+		return true
+	}
+	return decimalRegexp.MatchString(data)
+}
+
 // Returns a list of the maximum width of data supplied in data slice
 func get_columnsWidth(output_data_slice [][]string) (width_slice []int, err error) {
 
@@ -220,6 +380,23 @@ func CheckDir(file string) error {
 	return nil
 }
 
+// Writes a pivot table in the datamash CSV format (to the standard output if its name is "-"),
+// checking that the output directory exists
+func writePivotTable(fileName string, table *pivot.PivotTable) error {
+	if err := CheckDir(fileName); err != nil {
+		return err
+	}
+	out, err := createOutputFile(fileName)
+	if err != nil {
+		return err
+	}
+	if err := table.Write(out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
 // Based on the requested output filename (pivot table), builds a filename to store the history
 func generateHistoryFilename(outputFilename string, dataType InputType, isCompare bool) (historyFilename string) {
 
@@ -227,12 +404,7 @@ func generateHistoryFilename(outputFilename string, dataType InputType, isCompar
 	path := filepath.Dir(outputFilename)
 
 	//Compute filename elements based on parameters
-	historyFilenameType := ""
-	if dataType == InputTypeCommenters {
-		historyFilenameType = "commenters"
-	} else {
-		historyFilenameType = "submitters"
-	}
+	historyFilenameType := dataType.Name
 	extractType := ""
 	if isCompare {
 		extractType = "_evolution"
@@ -244,40 +416,34 @@ func generateHistoryFilename(outputFilename string, dataType InputType, isCompar
 }
 
 // Will retrieve and write the history line for all the top users
-func writeHistoryOutput(historyOutputFilename string, inputFilename string, dataType InputType, csv_output_slice [][]string) (err error) {
+func writeHistoryOutput(historyOutputFilename string, table *pivot.PivotTable, dataType InputType, csv_output_slice [][]string) (err error) {
 
 	// Check is the csv_output_slice is at least 1 record + tile long
 	if len(csv_output_slice) <= 2 {
 		return fmt.Errorf("The generated top user data seems empty.")
 	}
 
-	// Are we dealing with COMPARE type output (it has three columns)?
+	// Are we dealing with COMPARE type output (it has three columns, or more with the movement columns)?
 	// Note: this could have been a parameter for robustness. Can be refactored later (TODO:)
 	isCompare := false
-	expectedCompareColumnTitle := "status"
-	if len(csv_output_slice[0]) == 3 {
+	expectedCompareColumnTitle := strings.ToLower(dataType.CompareStatusColumn)
+	if len(csv_output_slice[0]) > 3 && strings.ToLower(csv_output_slice[0][2]) == expectedCompareColumnTitle {
+		isCompare = true
+	} else if len(csv_output_slice[0]) == 3 {
 		isCompare = true
 		if strings.ToLower(csv_output_slice[0][2]) != expectedCompareColumnTitle {
 			return fmt.Errorf("COMPARE output check failure: found three columns but third one doesn't have the expected title (found \"%s\" instead of \"%s\")", csv_output_slice[0][2], expectedCompareColumnTitle)
 		}
 	}
 
-	// Load the pivot table in memory
-	pivotRecords, loadErr := loadInputPivotTable(inputFilename)
-	if loadErr != nil {
-		return loadErr
-	}
-
 	//do we have data in the pivot table ?
-	if len(pivotRecords) <= 2 {
-		return fmt.Errorf("The pivot table (%s) seems empty.", inputFilename)
+	if table.NbrOfUsers() <= 1 {
+		return fmt.Errorf("The pivot table (%s) seems empty.", table.Source)
 	}
 
-	//This is a new slice that will contain the data to write
-	var historicDataSlice [][]string
-
-	//Get the title line and add it to the output
-	historicDataSlice = append(historicDataSlice, pivotRecords[0])
+	// The history lines, with the user handle possibly updated with its status
+	var historyUsers []string
+	var historyValues [][]int
 
 	for topUser_index, topUser_line := range csv_output_slice {
 
@@ -288,7 +454,7 @@ func writeHistoryOutput(historyOutputFilename string, inputFilename string, data
 
 		//get the line index of the line containing the top user's data
 		name := topUser_line[0]
-		index := getIndexInPivotTable(pivotRecords, name)
+		index := table.UserIndex(name)
 
 		//check that return value is not negative (not found)
 		if index == -1 {
@@ -304,22 +470,19 @@ func writeHistoryOutput(historyOutputFilename string, inputFilename string, data
 			}
 		}
 
-		//Update the pivot record with the (possibly) updated name
-		pivotRecords[index][0] = fullUsername
-
 		// Add the collected data
-		historicDataSlice = append(historicDataSlice, pivotRecords[index])
+		historyUsers = append(historyUsers, fullUsername)
+		historyValues = append(historyValues, table.Row(index))
+	}
+
+	historyTable, err := pivot.New(table.Months(), historyUsers, historyValues)
+	if err != nil {
+		return err
 	}
 
 	//figure out what the output directory is
 	historyBasePath := filepath.Dir(historyOutputFilename)
-	plotPath := ""
-	if dataType == InputTypeCommenters {
-		plotPath = filepath.Join(historyBasePath, "commentersPlot")
-	} else {
-		plotPath = filepath.Join(historyBasePath, "plot")
-	}
-	
+	plotPath := filepath.Join(historyBasePath, dataType.PlotDirectory)
 
 	//Create it as it doesn't exist and plot doesn't like that.
 	err = os.MkdirAll(plotPath, os.ModePerm)
@@ -328,28 +491,13 @@ func writeHistoryOutput(historyOutputFilename string, inputFilename string, data
 	}
 
 	//generate graphics
-	err = plotAllHistoryFiles(plotPath, historicDataSlice, dataType)
+	err = plotAllHistoryFiles(plotPath, historyTable, dataType)
 	if err != nil {
 		return err
 	}
 
 	//Write the CSV
-	writeCSVtoFile(historyOutputFilename, historicDataSlice)
+	writeCSVtoFile(historyOutputFilename, historyTable.Records())
 
 	return nil
 }
-
-// returns the index in the pivot record's slice with the supplied name.
-// Returns -1 if not found
-func getIndexInPivotTable(pivotRecords [][]string, name string) (index int) {
-	index = -1
-
-	for indexNbr, line := range pivotRecords {
-		if line[0] == name {
-			return indexNbr
-		}
-	}
-
-	return index
-
-}
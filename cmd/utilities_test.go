@@ -23,6 +23,7 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -30,6 +31,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/jenkins-infra/jenkins-contribution-aggregator/pivot"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -68,6 +70,22 @@ func Test_isFileValid(t *testing.T) {
 	}
 }
 
+func Test_validateInputFiles(t *testing.T) {
+	assert.NoError(t, validateInputFiles([]string{"../test_data/overview.csv", "-"}))
+	assert.EqualError(t, validateInputFiles([]string{"-", "../test_data/overview.csv", "-"}), "The standard input (\"-\") can only be used once\n")
+	assert.EqualError(t, validateInputFiles([]string{"-", "../test_data"}), "Invalid input file (../test_data)\n")
+}
+
+func Test_computeRanks(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 2, 4, 5, 5, 5}, computeRanks([]string{"9", "8", "8", "7", "3", "3", "3"}))
+	assert.Equal(t, []int{}, computeRanks([]string{}))
+}
+
+func Test_suffixedFileName(t *testing.T) {
+	assert.Equal(t, "out/timeline_summary.csv", suffixedFileName("out/timeline.csv", "_summary"))
+	assert.Equal(t, "timeline_summary", suffixedFileName("timeline", "_summary"))
+}
+
 func Test_validateMonth(t *testing.T) {
 	type args struct {
 		month     string
@@ -192,6 +210,28 @@ func Test_isWithMDfileExtension(t *testing.T) {
 	}
 }
 
+func Test_getOutputFormat(t *testing.T) {
+	tests := []struct {
+		name            string
+		filename        string
+		requestedFormat string
+		want            string
+	}{
+		{"Markdown extension", "myfile.md", "auto", outputFormatMarkdown},
+		{"JSON extension", "myfile.JSON", "auto", outputFormatJSON},
+		{"CSV extension", "myfile.csv", "auto", outputFormatCSV},
+		{"no extension", "myfile", "auto", outputFormatCSV},
+		{"no format", "myfile.json", "", outputFormatJSON},
+		{"requested format wins", "myfile.csv", "json", outputFormatJSON},
+		{"requested format (mixed case)", "myfile.json", "MD", outputFormatMarkdown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getOutputFormat(tt.filename, tt.requestedFormat))
+		})
+	}
+}
+
 func Test_writeMarkdownFile(t *testing.T) {
 	// Setup environment
 	tempDir := t.TempDir()
@@ -290,7 +330,9 @@ func Test_writeHistoryOutput(t *testing.T) {
 		{"daniel-beck", "164"}}
 
 	// Execute function under test
-	writeErr := writeHistoryOutput(testOutputFilename, inputPivotTableName, InputTypeSubmitters, data)
+	table, loadErr := pivot.Load(inputPivotTableName)
+	assert.NoError(t, loadErr, "Unexpected error loading the pivot table")
+	writeErr := writeHistoryOutput(testOutputFilename, table, InputTypeSubmitters, data)
 	assert.NoError(t, writeErr, "Function under test returned an unexpected error")
 
 	// *** result validation ***
@@ -335,7 +377,9 @@ func Test_writeHistoryOutput_compare(t *testing.T) {
 		{"daniel-beck", "164", ""}}
 
 	// Execute function under test
-	writeErr := writeHistoryOutput(testOutputFilename, inputPivotTableName, InputTypeSubmitters, data)
+	table, loadErr := pivot.Load(inputPivotTableName)
+	assert.NoError(t, loadErr, "Unexpected error loading the pivot table")
+	writeErr := writeHistoryOutput(testOutputFilename, table, InputTypeSubmitters, data)
 	assert.NoError(t, writeErr, "Function under test returned an unexpected error")
 
 	// *** result validation ***
@@ -376,7 +420,9 @@ func Test_writeHistoryOutput_notFoundUser(t *testing.T) {
 		{"daniel-beck", "164"}}
 
 	// Execute function under test
-	writeErr := writeHistoryOutput(testOutputFilename, inputPivotTableName, InputTypeSubmitters, data)
+	table, loadErr := pivot.Load(inputPivotTableName)
+	assert.NoError(t, loadErr, "Unexpected error loading the pivot table")
+	writeErr := writeHistoryOutput(testOutputFilename, table, InputTypeSubmitters, data)
 
 	assert.EqualErrorf(t, writeErr, "Supplied name (unknownUser) was not found in input pivot table file", "Function under test should have failed")
 
@@ -394,7 +440,9 @@ func Test_writeHistoryOutput_noTopUserData(t *testing.T) {
 	}
 
 	// Execute function under test
-	writeErr := writeHistoryOutput(testOutputFilename, inputPivotTableName, InputTypeSubmitters, data)
+	table, loadErr := pivot.Load(inputPivotTableName)
+	assert.NoError(t, loadErr, "Unexpected error loading the pivot table")
+	writeErr := writeHistoryOutput(testOutputFilename, table, InputTypeSubmitters, data)
 
 	assert.EqualErrorf(t, writeErr, "The generated top user data seems empty.", "Function under test should have failed")
 }
@@ -423,7 +471,9 @@ func Test_writeHistoryOutput_noPivotTableData(t *testing.T) {
 		{"daniel-beck", "164"}}
 
 	// Execute function under test
-	writeErr := writeHistoryOutput(testOutputFilename, inputPivotTableName, InputTypeSubmitters, data)
+	table, loadErr := pivot.Load(inputPivotTableName)
+	assert.NoError(t, loadErr, "Unexpected error loading the pivot table")
+	writeErr := writeHistoryOutput(testOutputFilename, table, InputTypeSubmitters, data)
 
 	assert.EqualErrorf(t, writeErr, "The pivot table (../test_data/noData_overview.csv) seems empty.", "Function under test should have failed")
 }
@@ -452,67 +502,14 @@ func Test_writeHistoryOutput_compareWithWrongHeader(t *testing.T) {
 		{"daniel-beck", "164", ""}}
 
 	// Execute function under test
-	writeErr := writeHistoryOutput(testOutputFilename, inputPivotTableName, InputTypeSubmitters, data)
+	table, loadErr := pivot.Load(inputPivotTableName)
+	assert.NoError(t, loadErr, "Unexpected error loading the pivot table")
+	writeErr := writeHistoryOutput(testOutputFilename, table, InputTypeSubmitters, data)
 
 	expectedErrorMessage := "COMPARE output check failure: found three columns but third one doesn't have the expected title (found \"junkHeader\" instead of \"status\")"
 	assert.EqualErrorf(t, writeErr, expectedErrorMessage, "Function under test should have failed")
 }
 
-func Test_getIndexInPivotTable(t *testing.T) {
-	testInputSlice := [][]string{
-		{"", "month_1", "month_2", "month_3"},
-		{"basil", "1245", "1", "2"},
-		{"MarkEWaite", "1150", "1", "2"},
-		{"lemeurherve", "939", "1", "2"},
-		{"NotMyFault", "926", "1", "2"},
-		{"dduportal", "859", "1", "2"},
-		{"jonesbusy", "415", "1", "2"},
-		{"jglick", "378", "1", "2"},
-		{"smerle33", "353", "1", "2"},
-		{"timja", "250", "1", "2"},
-		{"uhafner", "215", "1", "2"},
-		{"gounthar", "208", "1", "2"},
-		{"mawinter69", "179", "1", "2"},
-		{"daniel-beck", "164", "1", "2"}}
-
-	type args struct {
-		pivotRecords [][]string
-		name         string
-	}
-	tests := []struct {
-		name      string
-		args      args
-		wantIndex int
-	}{
-		{
-			"Happy case - 1",
-			args{pivotRecords: testInputSlice, name: "basil"},
-			1,
-		},
-		{
-			"Happy case - 2",
-			args{pivotRecords: testInputSlice, name: "uhafner"},
-			10,
-		},
-		{
-			"Happy case - 3",
-			args{pivotRecords: testInputSlice, name: "daniel-beck"},
-			13,
-		},
-		{
-			"notfound",
-			args{pivotRecords: testInputSlice, name: "jmm"},
-			-1,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if gotIndex := getIndexInPivotTable(tt.args.pivotRecords, tt.args.name); gotIndex != tt.wantIndex {
-				t.Errorf("getIndexInPivotTable() = %v, want %v", gotIndex, tt.wantIndex)
-			}
-		})
-	}
-}
 func Test_CheckDir(t *testing.T) {
 	type args struct {
 		file string
@@ -690,6 +687,15 @@ func isFileEquivalent(tempFileName, goldenFileName string) error {
 	return nil
 }
 
+// Converts raw records into a pivot table, failing the test if they are invalid
+func loadTestTable(t *testing.T, records [][]string) *pivot.PivotTable {
+	table, err := pivot.FromRecords(records)
+	if err != nil {
+		t.Fatalf("Unexpected error converting test records: %v", err)
+	}
+	return table
+}
+
 // load input file
 func loadFileToTest(fileName string) (error, []string) {
 
@@ -794,3 +800,22 @@ func Test_get_columnsWidth(t *testing.T) {
 		})
 	}
 }
+
+func Test_ExecuteExtractHistoryToStandardOutput(t *testing.T) {
+	// setup the command line
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"extract", "../test_data/overview.csv", "--history", "--out=-"})
+	defer func() {
+		isOutputHistory = false
+		outputFileName = "top-submitters_YYYY-MM.csv"
+	}()
+
+	// Execute the module under test
+	error := rootCmd.Execute()
+
+	// Check the results
+	assert.Error(t, error, "The history should not be written with the standard output")
+	assert.NoFileExists(t, "top_submitters_fullHistory.csv")
+}
@@ -3,6 +3,8 @@ module github.com/jenkins-infra/jenkins-contribution-aggregator
 go 1.20
 
 require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/klauspost/compress v1.17.9
 	github.com/spf13/cobra v1.7.0
 	github.com/stretchr/testify v1.9.0
 )
@@ -23,7 +25,7 @@ require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
+	github.com/spf13/pflag v1.0.5
 	gonum.org/v1/plot v0.14.0
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
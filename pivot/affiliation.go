@@ -0,0 +1,143 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Unaffiliated is the organization of the users without any affiliation for a month
+const Unaffiliated = "Unaffiliated"
+
+// Affiliation maps a login to the organization (employer, ...) the user contributes for
+type Affiliation struct {
+	Login        string
+	Organization string
+	// From and To limit the months of the affiliation (both included).
+	// A zero value means that the affiliation is not limited on that side.
+	From time.Time
+	To   time.Time
+}
+
+// Covers returns true if the user is affiliated to the organization for the month
+func (a Affiliation) Covers(month time.Time) bool {
+	return (a.From.IsZero() || !month.Before(a.From)) && (a.To.IsZero() || !month.After(a.To))
+}
+
+// ValidateAffiliations checks that a user is affiliated to only one organization for a given month
+func ValidateAffiliations(affiliations []Affiliation) error {
+	for i, affiliation := range affiliations {
+		if affiliation.Login == "" || affiliation.Organization == "" {
+			return fmt.Errorf("the login and its organization must be specified")
+		}
+		if !affiliation.From.IsZero() && !affiliation.To.IsZero() && affiliation.To.Before(affiliation.From) {
+			return fmt.Errorf("the affiliation of \"%s\" to \"%s\" ends (%s) before it starts (%s)", affiliation.Login, affiliation.Organization, FormatMonth(affiliation.To), FormatMonth(affiliation.From))
+		}
+		for _, other := range affiliations[:i] {
+			if sameLogin(affiliation.Login, other.Login) && overlap(affiliation.From, affiliation.To, other.From, other.To) {
+				return fmt.Errorf("\"%s\" is affiliated to both \"%s\" and \"%s\" for the same months", affiliation.Login, other.Organization, affiliation.Organization)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the organization of a user for a month (Unaffiliated if there is none)
+func organizationOf(affiliations []Affiliation, login string, month time.Time) string {
+	for _, affiliation := range affiliations {
+		if sameLogin(affiliation.Login, login) && affiliation.Covers(month) {
+			return affiliation.Organization
+		}
+	}
+	return Unaffiliated
+}
+
+// GroupByAffiliation returns a table with one row per organization, summing the contributions of
+// the users for the months they were affiliated to it. The contributions of the users without
+// affiliation are counted for Unaffiliated. The organizations are sorted by name. The affiliations
+// must be valid (see ValidateAffiliations).
+func (t *PivotTable) GroupByAffiliation(affiliations []Affiliation) (*PivotTable, error) {
+	rows := make(map[string][]int)
+	for row, user := range t.users {
+		for column, month := range t.months {
+			value := t.values[row][column]
+			if value == 0 {
+				continue
+			}
+			organization := organizationOf(affiliations, user, month)
+			if _, found := rows[organization]; !found {
+				rows[organization] = make([]int, len(t.months))
+			}
+			rows[organization][column] += value
+		}
+	}
+
+	organizations := make([]string, 0, len(rows))
+	for organization := range rows {
+		organizations = append(organizations, organization)
+	}
+	sort.Strings(organizations)
+	values := make([][]int, len(organizations))
+	for i, organization := range organizations {
+		values[i] = rows[organization]
+	}
+
+	grouped, err := New(t.Months(), organizations, values)
+	if err != nil {
+		return nil, err
+	}
+	grouped.Source = t.Source
+	return grouped, nil
+}
+
+// Members returns the contributions of the users counted for an organization (see GroupByAffiliation):
+// one row per user having contributed for it, with only the months they were affiliated to it.
+func (t *PivotTable) Members(affiliations []Affiliation, organization string) (*PivotTable, error) {
+	var users []string
+	var values [][]int
+	for row, user := range t.users {
+		var memberValues []int
+		for column, month := range t.months {
+			value := t.values[row][column]
+			if value == 0 || organizationOf(affiliations, user, month) != organization {
+				continue
+			}
+			if memberValues == nil {
+				memberValues = make([]int, len(t.months))
+			}
+			memberValues[column] = value
+		}
+		if memberValues != nil {
+			users = append(users, user)
+			values = append(values, memberValues)
+		}
+	}
+
+	members, err := New(t.Months(), users, values)
+	if err != nil {
+		return nil, err
+	}
+	members.Source = t.Source
+	return members, nil
+}
@@ -0,0 +1,204 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import (
+	"fmt"
+	"time"
+)
+
+// Alias is an additional login of a person (renamed account, work account, ...).
+// Its contributions are counted for the canonical login of the person.
+type Alias struct {
+	Login     string
+	Canonical string
+	// From and To limit the months the alias is valid for (both included).
+	// A zero value means that the validity is not limited on that side.
+	From time.Time
+	To   time.Time
+}
+
+// Covers returns true if the alias is valid for the month
+func (a Alias) Covers(month time.Time) bool {
+	return (a.From.IsZero() || !month.Before(a.From)) && (a.To.IsZero() || !month.After(a.To))
+}
+
+// ValidateAliases checks that the aliases can be applied: a login can't be its own alias, an
+// alias can't be itself a canonical login and a login can't be the alias of several persons
+// for the same month.
+func ValidateAliases(aliases []Alias) error {
+	canonicals := make(map[string]bool)
+	for _, alias := range aliases {
+		canonicals[loginKey(alias.Canonical)] = true
+	}
+
+	for i, alias := range aliases {
+		if alias.Login == "" || alias.Canonical == "" {
+			return fmt.Errorf("the alias and its canonical login must be specified")
+		}
+		if sameLogin(alias.Login, alias.Canonical) {
+			return fmt.Errorf("\"%s\" can't be an alias of itself", alias.Login)
+		}
+		if canonicals[loginKey(alias.Login)] {
+			return fmt.Errorf("\"%s\" is both an alias and a canonical login", alias.Login)
+		}
+		if !alias.From.IsZero() && !alias.To.IsZero() && alias.To.Before(alias.From) {
+			return fmt.Errorf("the validity of the alias \"%s\" ends (%s) before it starts (%s)", alias.Login, FormatMonth(alias.To), FormatMonth(alias.From))
+		}
+		for _, other := range aliases[:i] {
+			if sameLogin(alias.Login, other.Login) && overlap(alias.From, alias.To, other.From, other.To) {
+				return fmt.Errorf("\"%s\" is an alias of both \"%s\" and \"%s\" for the same months", alias.Login, other.Canonical, alias.Canonical)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns true if two periods of months overlap (a zero month means that the period is not limited on that side)
+func overlap(fromA time.Time, toA time.Time, fromB time.Time, toB time.Time) bool {
+	startsBeforeEnd := fromA.IsZero() || toB.IsZero() || !fromA.After(toB)
+	endsAfterStart := toA.IsZero() || fromB.IsZero() || !toA.Before(fromB)
+	return startsBeforeEnd && endsAfterStart
+}
+
+// findAlias returns the alias of a login valid for a month (nil if there is none)
+func findAlias(aliases []Alias, login string, month time.Time) *Alias {
+	for i, alias := range aliases {
+		if sameLogin(alias.Login, login) && alias.Covers(month) {
+			return &aliases[i]
+		}
+	}
+	return nil
+}
+
+// CanonicalLogin returns the canonical login of a user for a month (the login itself if it isn't an alias)
+func CanonicalLogin(aliases []Alias, login string, month time.Time) string {
+	if alias := findAlias(aliases, login, month); alias != nil {
+		return alias.Canonical
+	}
+	return login
+}
+
+// ApplyAliases returns a new table where the contributions of the aliases are moved to their
+// canonical login (the row is created if needed), for the months the aliases are valid.
+// The rows of the aliases left without any contribution are removed.
+// The merged aliases are returned (in the order of the table). The aliases must be valid (see ValidateAliases).
+func (t *PivotTable) ApplyAliases(aliases []Alias) (*PivotTable, []string, error) {
+	if len(aliases) == 0 {
+		return t, nil, nil
+	}
+
+	users := append([]string(nil), t.users...)
+	values := make([][]int, len(t.values))
+	for row := range t.values {
+		values[row] = append([]int(nil), t.values[row]...)
+	}
+	rows := make(map[string]int, len(users))
+	for row, user := range users {
+		if _, found := rows[loginKey(user)]; !found {
+			rows[loginKey(user)] = row
+		}
+	}
+
+	var merged []string
+	isMergedRow := make(map[int]bool)
+	for row, user := range t.users {
+		for column, month := range t.months {
+			alias := findAlias(aliases, user, month)
+			if alias == nil {
+				continue
+			}
+			isMergedRow[row] = true
+			canonicalRow, found := rows[loginKey(alias.Canonical)]
+			if !found {
+				canonicalRow = len(users)
+				rows[loginKey(alias.Canonical)] = canonicalRow
+				users = append(users, alias.Canonical)
+				values = append(values, make([]int, len(t.months)))
+			}
+			values[canonicalRow][column] += values[row][column]
+			values[row][column] = 0
+		}
+		if isMergedRow[row] {
+			merged = append(merged, user)
+		}
+	}
+	if len(merged) == 0 {
+		return t, nil, nil
+	}
+
+	// Remove the rows of the aliases left without any contribution
+	var keptUsers []string
+	var keptValues [][]int
+	for row, user := range users {
+		if isMergedRow[row] && isEmptyRow(values[row]) {
+			continue
+		}
+		keptUsers = append(keptUsers, user)
+		keptValues = append(keptValues, values[row])
+	}
+
+	result, err := New(t.Months(), keptUsers, keptValues)
+	if err != nil {
+		return nil, nil, err
+	}
+	result.Source = t.Source
+	result.details = t.details
+	return result, merged, nil
+}
+
+// Returns true if a row has no contribution
+func isEmptyRow(row []int) bool {
+	for _, value := range row {
+		if value != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckAliases reports the aliases whose canonical login also has a row in the table: both rows
+// are summed when the aliases are applied, which is expected for a renamed account but may
+// hide a wrong alias.
+func (t *PivotTable) CheckAliases(aliases []Alias, report *Report) {
+	for _, alias := range aliases {
+		aliasRow := t.userIndexFold(alias.Login)
+		canonicalRow := t.userIndexFold(alias.Canonical)
+		if aliasRow == -1 || canonicalRow == -1 {
+			continue
+		}
+		report.Add(0, 0, SeverityWarning, RuleAliasCollision, "User \"%s\" is an alias of \"%s\", which also has a row: their contributions will be merged", t.users[aliasRow], t.users[canonicalRow])
+	}
+}
+
+// Returns the row of a user, comparing the logins as logins (-1 if not found)
+func (t *PivotTable) userIndexFold(user string) int {
+	if row := t.UserIndex(user); row != -1 {
+		return row
+	}
+	for row, candidate := range t.users {
+		if sameLogin(candidate, user) {
+			return row
+		}
+	}
+	return -1
+}
@@ -0,0 +1,158 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Styles of the pseudonyms
+const (
+	// PseudonymNumbered numbers the users ("Contributor #1", "Contributor #2", ...)
+	PseudonymNumbered = "numbered"
+	// PseudonymHashed derives the pseudonyms from a salted hash of the logins ("Contributor 3f2a9c1b")
+	PseudonymHashed = "hashed"
+)
+
+// Pseudonymizer replaces the logins of the users by stable pseudonyms: a user keeps the same
+// pseudonym in all the tables anonymized by the same Pseudonymizer.
+// The numbered pseudonyms are assigned in descending order of the contributions of the first
+// table the users are found in. The hashed pseudonyms only depend on the salt and the login
+// (regardless of the case), and are thus stable from one run to another.
+type Pseudonymizer struct {
+	style string
+	salt  string
+	// isAnonymizingAll is true when all the users are anonymized, not only the ones of optOut
+	isAnonymizingAll bool
+	optOut           *AccountFilter
+
+	pseudonyms map[string]string
+	logins     map[string]string
+}
+
+// NewPseudonymizer builds a Pseudonymizer of the given style. All the users are anonymized if
+// isAnonymizingAll is true, otherwise only the ones matching the opt-out filter (which may be nil).
+// The hashed style requires a salt, so that the pseudonyms can't be matched against known logins.
+func NewPseudonymizer(style string, salt string, isAnonymizingAll bool, optOut *AccountFilter) (*Pseudonymizer, error) {
+	switch style {
+	case PseudonymNumbered:
+	case PseudonymHashed:
+		if salt == "" {
+			return nil, fmt.Errorf("a salt is required for the hashed pseudonyms")
+		}
+	default:
+		return nil, fmt.Errorf("%s is an invalid pseudonym style (should be \"%s\" or \"%s\")", style, PseudonymNumbered, PseudonymHashed)
+	}
+	return &Pseudonymizer{
+		style:            style,
+		salt:             salt,
+		isAnonymizingAll: isAnonymizingAll,
+		optOut:           optOut,
+		pseudonyms:       make(map[string]string),
+		logins:           make(map[string]string),
+	}, nil
+}
+
+// IsEnabled returns true if some users are to be anonymized
+func (p *Pseudonymizer) IsEnabled() bool {
+	return p != nil && (p.isAnonymizingAll || !p.optOut.IsEmpty())
+}
+
+// IsAnonymized returns true if the login must be replaced by a pseudonym
+func (p *Pseudonymizer) IsAnonymized(login string) bool {
+	return p.IsEnabled() && (p.isAnonymizingAll || p.optOut.Match(login))
+}
+
+// Pseudonym returns the pseudonym assigned to a login (false if none was assigned yet)
+func (p *Pseudonymizer) Pseudonym(login string) (string, bool) {
+	pseudonym, found := p.pseudonyms[strings.ToLower(login)]
+	return pseudonym, found
+}
+
+// Assigns a pseudonym to a login (if it doesn't have one yet)
+func (p *Pseudonymizer) assign(login string) (string, error) {
+	if pseudonym, found := p.Pseudonym(login); found {
+		return pseudonym, nil
+	}
+
+	var pseudonym string
+	if p.style == PseudonymHashed {
+		hash := sha256.Sum256([]byte(p.salt + strings.ToLower(login)))
+		pseudonym = "Contributor " + hex.EncodeToString(hash[:4])
+	} else {
+		pseudonym = fmt.Sprintf("Contributor #%d", len(p.pseudonyms)+1)
+	}
+	if other, found := p.logins[pseudonym]; found {
+		return "", fmt.Errorf("\"%s\" and \"%s\" have the same pseudonym (try another salt)", other, login)
+	}
+	p.pseudonyms[strings.ToLower(login)] = pseudonym
+	p.logins[pseudonym] = login
+	return pseudonym, nil
+}
+
+// Anonymize returns a new table where the logins of the anonymized users are replaced by their
+// pseudonym. The rows and the values are unchanged. Returns the number of anonymized users.
+func (t *PivotTable) Anonymize(p *Pseudonymizer) (*PivotTable, int, error) {
+	if !p.IsEnabled() {
+		return t, 0, nil
+	}
+
+	// The users without pseudonym get one in descending order of their contributions
+	// (in the order of the table for the same total, so that the numbering is reproducible)
+	var totals []Total
+	for row, user := range t.users {
+		if _, found := p.Pseudonym(user); !found && p.IsAnonymized(user) {
+			totals = append(totals, Total{User: user, Total: t.Sum(row, 0, t.NbrOfMonths()-1)})
+		}
+	}
+	sort.SliceStable(totals, func(i, j int) bool { return totals[i].Total > totals[j].Total })
+	for _, total := range totals {
+		if _, err := p.assign(total.User); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	users := make([]string, len(t.users))
+	anonymized := 0
+	for row, user := range t.users {
+		users[row] = user
+		if p.IsAnonymized(user) {
+			users[row], _ = p.Pseudonym(user)
+			anonymized++
+		}
+	}
+	if anonymized == 0 {
+		return t, 0, nil
+	}
+
+	result, err := New(t.Months(), users, t.values)
+	if err != nil {
+		return nil, 0, err
+	}
+	result.Source = t.Source
+	result.details = t.details
+	return result, anonymized, nil
+}
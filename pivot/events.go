@@ -0,0 +1,220 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Event is a single contribution (a PR or a comment) as exported by the upstream scripts
+type Event struct {
+	User      string    `json:"user"`
+	Repo      string    `json:"repo"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Formats of the event files
+const (
+	EventFormatCSV   = "csv"   // CSV with a "user,repo,created_at" header (in any order)
+	EventFormatJSONL = "jsonl" // JSON Lines, one {"user", "repo", "created_at"} object per line
+)
+
+// Layouts accepted for the event creation dates (a date without time zone is in UTC)
+var eventTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// ReadEvents reads contribution events in the given format (EventFormatCSV or EventFormatJSONL)
+func ReadEvents(r io.Reader, format string) ([]Event, error) {
+	switch format {
+	case EventFormatCSV:
+		return readCSVEvents(r)
+	case EventFormatJSONL:
+		return readJSONLEvents(r)
+	default:
+		return nil, fmt.Errorf("Unknown event format \"%s\"", format)
+	}
+}
+
+func readCSVEvents(r io.Reader) ([]Event, error) {
+	csvReader := csv.NewReader(r)
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("The event file is empty")
+	}
+
+	columns := map[string]int{"user": -1, "repo": -1, "created_at": -1}
+	for i, columnName := range records[0] {
+		if _, found := columns[strings.ToLower(strings.TrimSpace(columnName))]; found {
+			columns[strings.ToLower(strings.TrimSpace(columnName))] = i
+		}
+	}
+	for _, columnName := range []string{"user", "created_at"} {
+		if columns[columnName] == -1 {
+			return nil, eventError(1, 0, fmt.Errorf("Missing \"%s\" column", columnName))
+		}
+	}
+
+	var events []Event
+	for i, record := range records[1:] {
+		lineNbr := i + 2
+		event := Event{User: strings.TrimSpace(record[columns["user"]])}
+		if columns["repo"] != -1 {
+			event.Repo = strings.TrimSpace(record[columns["repo"]])
+		}
+		event.CreatedAt, err = parseEventTime(record[columns["created_at"]])
+		if err != nil {
+			return nil, eventError(lineNbr, columns["created_at"]+1, err)
+		}
+		if err := validateEvent(event); err != nil {
+			return nil, eventError(lineNbr, 0, err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func readJSONLEvents(r io.Reader) ([]Event, error) {
+	// The date is parsed separately to accept the same layouts as in the CSV files
+	var raw struct {
+		User      string `json:"user"`
+		Repo      string `json:"repo"`
+		CreatedAt string `json:"created_at"`
+	}
+
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNbr := 0
+	for scanner.Scan() {
+		lineNbr++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		raw.User, raw.Repo, raw.CreatedAt = "", "", ""
+		if err := json.Unmarshal([]byte(line), &raw); err != nil {
+			return nil, eventError(lineNbr, 0, err)
+		}
+		createdAt, err := parseEventTime(raw.CreatedAt)
+		if err != nil {
+			return nil, eventError(lineNbr, 0, err)
+		}
+		event := Event{User: strings.TrimSpace(raw.User), Repo: strings.TrimSpace(raw.Repo), CreatedAt: createdAt}
+		if err := validateEvent(event); err != nil {
+			return nil, eventError(lineNbr, 0, err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Prefixes an error with its position in the event file
+func eventError(line int, column int, err error) error {
+	return errors.New(withCoordinates(line, column, err.Error()))
+}
+
+// Parses an event creation date with one of the accepted layouts
+func parseEventTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range eventTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("\"%s\" is not a valid date (expecting RFC 3339, e.g. 2023-01-31T12:00:00Z)", value)
+}
+
+func validateEvent(event Event) error {
+	if !IsValidUser(event.User) {
+		return fmt.Errorf("User \"%s\" does not follow GitHub rules", event.User)
+	}
+	return nil
+}
+
+// FromEvents counts the events per user and per month and builds the pivot table.
+// The events are bucketed by month in the given location (UTC if nil).
+// As with datamash, the users are sorted alphabetically. The months are contiguous
+// from the first to the last event (months without any event are filled with 0).
+func FromEvents(events []Event, location *time.Location) (*PivotTable, error) {
+	if len(events) == 0 {
+		return nil, fmt.Errorf("No event to aggregate")
+	}
+	if location == nil {
+		location = time.UTC
+	}
+
+	counts := make(map[string]map[time.Time]int)
+	var firstMonth, lastMonth time.Time
+	for _, event := range events {
+		month := StartOfMonth(event.CreatedAt.In(location))
+		if firstMonth.IsZero() || month.Before(firstMonth) {
+			firstMonth = month
+		}
+		if month.After(lastMonth) {
+			lastMonth = month
+		}
+		if counts[event.User] == nil {
+			counts[event.User] = make(map[time.Time]int)
+		}
+		counts[event.User][month]++
+	}
+
+	users := make([]string, 0, len(counts))
+	for user := range counts {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	nbrOfMonths := MonthsBetween(firstMonth, lastMonth) + 1
+	months := make([]time.Time, nbrOfMonths)
+	for i := range months {
+		months[i] = AddMonths(firstMonth, i)
+	}
+
+	values := make([][]int, len(users))
+	for i, user := range users {
+		values[i] = make([]int, nbrOfMonths)
+		for month, count := range counts[user] {
+			values[i][MonthsBetween(firstMonth, month)] = count
+		}
+	}
+
+	return New(months, users, values)
+}
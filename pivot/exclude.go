@@ -0,0 +1,155 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// BotPatterns are the accounts of the most common bots (dependency updaters, CI, ...)
+var BotPatterns = []string{
+	"dependabot",
+	"dependabot-preview",
+	"renovate",
+	"renovate-bot",
+	"github-actions",
+	"*-bot",
+	"*[bot]",
+}
+
+// AccountFilter matches the accounts to exclude from the tables.
+// A pattern is either:
+//   - a regular expression, enclosed in slashes (e.g. "/^jenkins-.*-ci$/"),
+//   - a glob, if it contains a "*" (any sequence of characters) or a "?" (any character),
+//   - a login otherwise.
+//
+// Logins and globs are matched as logins (see sameLogin).
+type AccountFilter struct {
+	patterns []*regexp.Regexp
+}
+
+// NewAccountFilter compiles the patterns of the accounts to exclude
+func NewAccountFilter(patterns []string) (*AccountFilter, error) {
+	filter := &AccountFilter{}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		var expression string
+		switch {
+		case len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/"):
+			expression = pattern[1 : len(pattern)-1]
+		case strings.ContainsAny(pattern, "*?"):
+			expression = loginExpression(globToExpression(pattern))
+		default:
+			expression = loginExpression(regexp.QuoteMeta(pattern))
+		}
+
+		compiled, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclusion pattern \"%s\": %v", pattern, err)
+		}
+		filter.patterns = append(filter.patterns, compiled)
+	}
+	return filter, nil
+}
+
+// Converts a glob to a regular expression: only "*" and "?" are special characters
+func globToExpression(glob string) string {
+	var expression strings.Builder
+	for _, char := range glob {
+		switch char {
+		case '*':
+			expression.WriteString(".*")
+		case '?':
+			expression.WriteString(".")
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+	return expression.String()
+}
+
+// ReadAccountPatterns reads exclusion patterns, one per line.
+// Empty lines and lines starting with "#" are ignored.
+func ReadAccountPatterns(r io.Reader) ([]string, error) {
+	var patterns []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+// IsEmpty returns true if the filter doesn't exclude anything
+func (f *AccountFilter) IsEmpty() bool {
+	return f == nil || len(f.patterns) == 0
+}
+
+// Match returns true if the account must be excluded
+func (f *AccountFilter) Match(user string) bool {
+	if f == nil {
+		return false
+	}
+	for _, pattern := range f.patterns {
+		if pattern.MatchString(user) {
+			return true
+		}
+	}
+	return false
+}
+
+// Exclude returns a new table without the accounts matched by the filter, and the excluded accounts
+// (in the order of the table). The table itself is returned if no account is excluded.
+func (t *PivotTable) Exclude(filter *AccountFilter) (*PivotTable, []string, error) {
+	var users, excluded []string
+	var values [][]int
+	for row, user := range t.users {
+		if filter.Match(user) {
+			excluded = append(excluded, user)
+			continue
+		}
+		users = append(users, user)
+		values = append(values, t.values[row])
+	}
+	if len(excluded) == 0 {
+		return t, nil, nil
+	}
+
+	filtered, err := New(t.Months(), users, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	filtered.Source = t.Source
+	filtered.details = t.details
+	return filtered, excluded, nil
+}
@@ -0,0 +1,44 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import "strings"
+
+// GitHub logins are case insensitive: "Basil" and "basil" are the same account. The logins
+// given by the users (exclusions, aliases, affiliations) are matched with the functions below,
+// whatever the case used in the pivot tables.
+
+// sameLogin returns true if two logins are the ones of the same account
+func sameLogin(login string, other string) bool {
+	return strings.EqualFold(login, other)
+}
+
+// loginKey returns the key of a login in the maps indexed by account
+func loginKey(login string) string {
+	return strings.ToLower(login)
+}
+
+// loginExpression returns a regular expression matching the whole login, for an expression
+// matching its characters
+func loginExpression(expression string) string {
+	return "(?i)^" + expression + "$"
+}
@@ -0,0 +1,387 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Columns of a long-format table, in any order and regardless of the case.
+// The "user" and "month" columns are mandatory: a line without "count" column counts for 1.
+const (
+	LongColumnUser   = "user"
+	LongColumnMonth  = "month"
+	LongColumnRepo   = "repo"   // "repository" is accepted too
+	LongColumnLabels = "labels" // "label" is accepted too
+	LongColumnCount  = "count"
+)
+
+// LabelSeparator separates the labels of a contribution in the labels column
+const LabelSeparator = ";"
+
+// Contribution is a line of a long-format table: contributions of a user during a month,
+// to a repository and with some labels (both optional)
+type Contribution struct {
+	User   string
+	Month  time.Time
+	Repo   string
+	Labels []string
+	Count  int
+}
+
+// LongTable is a table of contributions in the long format (one line per user, month, repository
+// and labels), as opposed to the datamash pivot tables (one row per user, one column per month).
+// It gives the details needed to filter the contributions and to break them down per repository.
+type LongTable struct {
+	// Source is the name of the file the table was loaded from (empty if built in memory)
+	Source string
+
+	contributions []Contribution
+	hasRepos      bool
+	hasLabels     bool
+	// firstMonth and lastMonth are the months range of the loaded table, kept by the filtered tables
+	firstMonth time.Time
+	lastMonth  time.Time
+}
+
+// RepoTotal is the accumulated number of contributions to a repository over a period
+type RepoTotal struct {
+	Repo  string
+	Total int
+}
+
+// Contributions returns the lines of the table
+func (l *LongTable) Contributions() []Contribution {
+	return l.contributions
+}
+
+// HasRepos returns true if the table has a repository column
+func (l *LongTable) HasRepos() bool {
+	return l.hasRepos
+}
+
+// HasLabels returns true if the table has a labels column
+func (l *LongTable) HasLabels() bool {
+	return l.hasLabels
+}
+
+// Returns the index of the long-format columns found in the header (-1 if missing)
+func longColumns(header []string) map[string]int {
+	columns := map[string]int{
+		LongColumnUser:   -1,
+		LongColumnMonth:  -1,
+		LongColumnRepo:   -1,
+		LongColumnLabels: -1,
+		LongColumnCount:  -1,
+	}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "repository":
+			name = LongColumnRepo
+		case "label":
+			name = LongColumnLabels
+		}
+		if index, found := columns[name]; found && index == -1 {
+			columns[name] = i
+		}
+	}
+	return columns
+}
+
+// IsLongFormat returns true if the header is the one of a long-format table (with a "user" and a "month" column)
+func IsLongFormat(header []string) bool {
+	columns := longColumns(header)
+	return columns[LongColumnUser] != -1 && columns[LongColumnMonth] != -1
+}
+
+// CheckLongRecords validates the raw CSV records (header included) of a long-format table,
+// collecting every issue found. The table is nil if the report contains errors.
+func CheckLongRecords(records [][]string) (*LongTable, *Report) {
+	report := &Report{}
+
+	if len(records) == 0 {
+		report.Add(0, 0, SeverityError, RuleHeader, "The table is empty")
+		return nil, report
+	}
+	header := records[0]
+	if !IsLongFormat(header) {
+		report.Add(1, 0, SeverityError, RuleHeader, "The long-format table must have a \"%s\" and a \"%s\" column", LongColumnUser, LongColumnMonth)
+		return nil, report
+	}
+	columns := longColumns(header)
+
+	table := &LongTable{
+		hasRepos:  columns[LongColumnRepo] != -1,
+		hasLabels: columns[LongColumnLabels] != -1,
+	}
+	for i, record := range records[1:] {
+		lineNbr := i + 2
+
+		if len(record) != len(header) {
+			report.Add(lineNbr, 0, SeverityError, RuleRowLength, "Line has %d columns while the header defines %d", len(record), len(header))
+			continue
+		}
+
+		contribution := Contribution{User: record[columns[LongColumnUser]], Count: 1}
+		validateUserAt(report, contribution.User, lineNbr, columns[LongColumnUser]+1)
+
+		month, err := ParseMonth(record[columns[LongColumnMonth]])
+		if err != nil {
+			report.Add(lineNbr, columns[LongColumnMonth]+1, SeverityError, RuleMonthFormat, "Month %s is not of the expected format (YYYY-MM)", record[columns[LongColumnMonth]])
+		}
+		contribution.Month = month
+
+		if column := columns[LongColumnRepo]; column != -1 {
+			contribution.Repo = strings.TrimSpace(record[column])
+		}
+		if column := columns[LongColumnLabels]; column != -1 {
+			for _, label := range strings.Split(record[column], LabelSeparator) {
+				if label = strings.TrimSpace(label); label != "" {
+					contribution.Labels = append(contribution.Labels, label)
+				}
+			}
+		}
+		if column := columns[LongColumnCount]; column != -1 {
+			contribution.Count, _ = validateValue(report, record[column], lineNbr, column+1)
+		}
+
+		table.contributions = append(table.contributions, contribution)
+		if table.firstMonth.IsZero() || month.Before(table.firstMonth) {
+			table.firstMonth = month
+		}
+		if month.After(table.lastMonth) {
+			table.lastMonth = month
+		}
+	}
+
+	if report.HasErrors() {
+		return nil, report
+	}
+	if len(table.contributions) == 0 {
+		report.Add(0, 0, SeverityError, RuleHeader, "The table has no contribution")
+		return nil, report
+	}
+	return table, report
+}
+
+// Pivot counts the contributions per user and per month and builds the pivot table.
+// As with datamash, the users are sorted alphabetically. The months are contiguous
+// from the first to the last month of the table (missing months are filled with 0).
+// A filtered table keeps the months of the table it was filtered from, so that the
+// latest month doesn't depend on the filters.
+func (l *LongTable) Pivot() (*PivotTable, error) {
+	if len(l.contributions) == 0 {
+		return nil, fmt.Errorf("No contribution to aggregate")
+	}
+
+	counts := make(map[string]map[time.Time]int)
+	firstMonth, lastMonth := l.firstMonth, l.lastMonth
+	for _, contribution := range l.contributions {
+		if firstMonth.IsZero() || contribution.Month.Before(firstMonth) {
+			firstMonth = contribution.Month
+		}
+		if contribution.Month.After(lastMonth) {
+			lastMonth = contribution.Month
+		}
+		if counts[contribution.User] == nil {
+			counts[contribution.User] = make(map[time.Time]int)
+		}
+		counts[contribution.User][contribution.Month] += contribution.Count
+	}
+
+	users := make([]string, 0, len(counts))
+	for user := range counts {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	nbrOfMonths := MonthsBetween(firstMonth, lastMonth) + 1
+	months := make([]time.Time, nbrOfMonths)
+	for i := range months {
+		months[i] = AddMonths(firstMonth, i)
+	}
+
+	values := make([][]int, len(users))
+	for i, user := range users {
+		values[i] = make([]int, nbrOfMonths)
+		for month, count := range counts[user] {
+			values[i][MonthsBetween(firstMonth, month)] = count
+		}
+	}
+
+	table, err := New(months, users, values)
+	if err != nil {
+		return nil, err
+	}
+	table.Source = l.Source
+	table.details = l
+	return table, nil
+}
+
+// ContributionFilter selects the contributions on their repository and their labels.
+// The patterns are globs, with "*" matching any sequence of characters and "?" any character,
+// compared regardless of the case. A pattern starting with "!" excludes the matching contributions.
+type ContributionFilter struct {
+	repos          []*regexp.Regexp
+	excludedRepos  []*regexp.Regexp
+	labels         []*regexp.Regexp
+	excludedLabels []*regexp.Regexp
+}
+
+// NewContributionFilter compiles the patterns of the repositories and of the labels.
+// A contribution is kept if:
+//   - its repository matches one of the repository patterns (if any) and none of the excluded ones,
+//   - one of its labels matches one of the label patterns (if any) and none matches the excluded ones.
+func NewContributionFilter(repoPatterns []string, labelPatterns []string) (*ContributionFilter, error) {
+	filter := &ContributionFilter{}
+	var err error
+	if filter.repos, filter.excludedRepos, err = compileGlobs(repoPatterns); err != nil {
+		return nil, err
+	}
+	if filter.labels, filter.excludedLabels, err = compileGlobs(labelPatterns); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// Compiles the globs, separating the included ones from the excluded ones (starting with "!")
+func compileGlobs(patterns []string) (included []*regexp.Regexp, excluded []*regexp.Regexp, err error) {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		isExcluded := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if pattern == "" {
+			continue
+		}
+
+		compiled, err := regexp.Compile("(?i)^" + globToExpression(pattern) + "$")
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid pattern \"%s\": %v", pattern, err)
+		}
+		if isExcluded {
+			excluded = append(excluded, compiled)
+		} else {
+			included = append(included, compiled)
+		}
+	}
+	return included, excluded, nil
+}
+
+// Returns true if one of the values matches one of the expressions
+func matchAny(expressions []*regexp.Regexp, values ...string) bool {
+	for _, expression := range expressions {
+		for _, value := range values {
+			if expression.MatchString(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsEmpty returns true if the filter keeps all the contributions
+func (f *ContributionFilter) IsEmpty() bool {
+	return f == nil || len(f.repos)+len(f.excludedRepos)+len(f.labels)+len(f.excludedLabels) == 0
+}
+
+// IsFilteringRepos returns true if the filter selects the contributions on their repository
+func (f *ContributionFilter) IsFilteringRepos() bool {
+	return f != nil && len(f.repos)+len(f.excludedRepos) > 0
+}
+
+// IsFilteringLabels returns true if the filter selects the contributions on their labels
+func (f *ContributionFilter) IsFilteringLabels() bool {
+	return f != nil && len(f.labels)+len(f.excludedLabels) > 0
+}
+
+// Match returns true if the contribution is kept by the filter
+func (f *ContributionFilter) Match(contribution Contribution) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.repos) > 0 && !matchAny(f.repos, contribution.Repo) {
+		return false
+	}
+	if matchAny(f.excludedRepos, contribution.Repo) {
+		return false
+	}
+	if len(f.labels) > 0 && !matchAny(f.labels, contribution.Labels...) {
+		return false
+	}
+	return !matchAny(f.excludedLabels, contribution.Labels...)
+}
+
+// Filter returns a new table with the contributions kept by the filter
+func (l *LongTable) Filter(filter *ContributionFilter) *LongTable {
+	filtered := &LongTable{Source: l.Source, hasRepos: l.hasRepos, hasLabels: l.hasLabels, firstMonth: l.firstMonth, lastMonth: l.lastMonth}
+	for _, contribution := range l.contributions {
+		if filter.Match(contribution) {
+			filtered.contributions = append(filtered.contributions, contribution)
+		}
+	}
+	return filtered
+}
+
+// RepoTotals returns, for every user, the sum of the contributions per repository between two months
+// (both included), sorted by descending total (and by repository for the same total).
+// The users are keyed by the name returned by the login function, which can also skip a
+// contribution (to follow the aliases or the exclusions applied to the pivot table for instance).
+// The contributions without repository are not counted.
+func (l *LongTable) RepoTotals(from time.Time, to time.Time, login func(user string, month time.Time) (string, bool)) map[string][]RepoTotal {
+	sums := make(map[string]map[string]int)
+	for _, contribution := range l.contributions {
+		if contribution.Repo == "" || contribution.Month.Before(from) || contribution.Month.After(to) {
+			continue
+		}
+		user, isCounted := login(contribution.User, contribution.Month)
+		if !isCounted {
+			continue
+		}
+		if sums[user] == nil {
+			sums[user] = make(map[string]int)
+		}
+		sums[user][contribution.Repo] += contribution.Count
+	}
+
+	totals := make(map[string][]RepoTotal, len(sums))
+	for user, repos := range sums {
+		var userTotals []RepoTotal
+		for repo, total := range repos {
+			if total > 0 {
+				userTotals = append(userTotals, RepoTotal{Repo: repo, Total: total})
+			}
+		}
+		sort.Slice(userTotals, func(i, j int) bool {
+			if userTotals[i].Total != userTotals[j].Total {
+				return userTotals[i].Total > userTotals[j].Total
+			}
+			return userTotals[i].Repo < userTotals[j].Repo
+		})
+		totals[user] = userTotals
+	}
+	return totals
+}
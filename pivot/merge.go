@@ -0,0 +1,158 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Conflict reports a month defined in several merged tables with different values
+type Conflict struct {
+	Month time.Time
+	// Sources are the tables defining the month
+	Sources []string
+	// Users are the users with different values for the month (a missing user counts as 0)
+	Users []string
+}
+
+// String describes the conflict
+func (c Conflict) String() string {
+	return fmt.Sprintf("Month %s is defined with different values in %s (%d users)", FormatMonth(c.Month), strings.Join(c.Sources, ", "), len(c.Users))
+}
+
+// Merge combines several tables into one: the months and the users are united and the
+// values of the same user and month are summed. Months missing between the first and
+// the last one are filled with 0, so that the result is contiguous.
+//
+// A month defined in several tables is normal when merging tables of different scopes
+// (GitHub organizations for instance), but suspicious when merging consecutive periods
+// of the same scope. Such months are returned as conflicts when the values differ.
+func Merge(tables ...*PivotTable) (*PivotTable, []Conflict, error) {
+	if len(tables) == 0 {
+		return nil, nil, fmt.Errorf("No pivot table to merge")
+	}
+
+	// Unite the months and the users (in order of first appearance)
+	var firstMonth, lastMonth time.Time
+	var users []string
+	userRows := make(map[string]int)
+	for _, table := range tables {
+		if table.NbrOfMonths() == 0 {
+			continue
+		}
+		if firstMonth.IsZero() || table.Month(0).Before(firstMonth) {
+			firstMonth = table.Month(0)
+		}
+		if last := table.Month(table.NbrOfMonths() - 1); last.After(lastMonth) {
+			lastMonth = last
+		}
+		for _, user := range table.users {
+			if _, found := userRows[user]; !found {
+				userRows[user] = len(users)
+				users = append(users, user)
+			}
+		}
+	}
+	if firstMonth.IsZero() {
+		return nil, nil, fmt.Errorf("No monthly data available in the pivot tables to merge")
+	}
+
+	nbrOfMonths := MonthsBetween(firstMonth, lastMonth) + 1
+	months := make([]time.Time, nbrOfMonths)
+	for i := range months {
+		months[i] = AddMonths(firstMonth, i)
+	}
+
+	// Sum the values
+	values := make([][]int, len(users))
+	for i := range values {
+		values[i] = make([]int, nbrOfMonths)
+	}
+	for _, table := range tables {
+		for i, user := range table.users {
+			row := userRows[user]
+			for ii, month := range table.months {
+				values[row][MonthsBetween(firstMonth, month)] += table.values[i][ii]
+			}
+		}
+	}
+
+	merged, err := New(months, users, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	return merged, findConflicts(tables, months, users), nil
+}
+
+// Lists the months defined in several tables with different values
+func findConflicts(tables []*PivotTable, months []time.Time, users []string) []Conflict {
+	var conflicts []Conflict
+	for _, month := range months {
+		var definingTables []*PivotTable
+		for _, table := range tables {
+			if table.MonthIndex(month) != -1 {
+				definingTables = append(definingTables, table)
+			}
+		}
+		if len(definingTables) < 2 {
+			continue
+		}
+
+		var conflictingUsers []string
+		for _, user := range users {
+			reference, _ := definingTables[0].Lookup(user, month)
+			for _, table := range definingTables[1:] {
+				if value, _ := table.Lookup(user, month); value != reference {
+					conflictingUsers = append(conflictingUsers, user)
+					break
+				}
+			}
+		}
+		if len(conflictingUsers) == 0 {
+			continue
+		}
+
+		var sources []string
+		for _, table := range definingTables {
+			sources = append(sources, sourceName(table, tables))
+		}
+		sort.Strings(conflictingUsers)
+		conflicts = append(conflicts, Conflict{Month: month, Sources: sources, Users: conflictingUsers})
+	}
+	return conflicts
+}
+
+// Name used to identify a table in the messages (its position if it wasn't loaded from a file)
+func sourceName(table *PivotTable, tables []*PivotTable) string {
+	if table.Source != "" {
+		return table.Source
+	}
+	for i, candidate := range tables {
+		if candidate == table {
+			return fmt.Sprintf("table #%d", i+1)
+		}
+	}
+	return "unknown table"
+}
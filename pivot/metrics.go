@@ -0,0 +1,123 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import (
+	"sort"
+	"time"
+)
+
+// Metrics are the health indicators of a community over a period
+type Metrics struct {
+	// Month is the last month of the period
+	Month time.Time
+	// Start is the first month of the period
+	Start time.Time
+	// ActiveUsers is the number of users with at least one contribution
+	ActiveUsers int
+	// Total is the number of contributions
+	Total int
+	// BusFactor50 and BusFactor80 are the minimum number of users covering 50% and 80% of the contributions
+	BusFactor50 int
+	BusFactor80 int
+	// Gini is the Gini coefficient of the contributions of the active users
+	// (0 when everybody contributes equally, close to 1 when one user does everything)
+	Gini float64
+	// Herfindahl is the sum of the squared shares of the users (1/ActiveUsers to 1)
+	Herfindahl float64
+}
+
+// MetricsBetween computes the health indicators of the contributions between two columns (both included)
+func (t *PivotTable) MetricsBetween(from int, to int) Metrics {
+	var totals []int
+	for _, total := range t.Totals(from, to) {
+		totals = append(totals, total.Total)
+	}
+	metrics := ComputeMetrics(totals)
+	metrics.Start = t.months[from]
+	metrics.Month = t.months[to]
+	return metrics
+}
+
+// RollingMetrics computes the health indicators of every window of "window" months ending
+// between two columns (both included). Windows starting before the first month are skipped.
+func (t *PivotTable) RollingMetrics(from int, to int, window int) []Metrics {
+	if window < 1 {
+		window = 1
+	}
+	var series []Metrics
+	for end := from; end <= to; end++ {
+		if start := end - window + 1; start >= 0 {
+			series = append(series, t.MetricsBetween(start, end))
+		}
+	}
+	return series
+}
+
+// ComputeMetrics computes the health indicators from the contributions of every user.
+// Users without any contribution are ignored.
+func ComputeMetrics(totals []int) Metrics {
+	var active []int
+	var metrics Metrics
+	for _, total := range totals {
+		if total > 0 {
+			active = append(active, total)
+			metrics.Total += total
+		}
+	}
+	metrics.ActiveUsers = len(active)
+	if metrics.Total == 0 {
+		return metrics
+	}
+
+	// Bus factors: the biggest contributors first
+	sort.Sort(sort.Reverse(sort.IntSlice(active)))
+	metrics.BusFactor50 = busFactor(active, metrics.Total, 0.5)
+	metrics.BusFactor80 = busFactor(active, metrics.Total, 0.8)
+
+	// Gini coefficient: the smallest contributors first
+	sort.Ints(active)
+	n := float64(len(active))
+	weightedSum := 0.0
+	for i, total := range active {
+		weightedSum += (2*float64(i+1) - n - 1) * float64(total)
+	}
+	metrics.Gini = weightedSum / (n * float64(metrics.Total))
+
+	for _, total := range active {
+		share := float64(total) / float64(metrics.Total)
+		metrics.Herfindahl += share * share
+	}
+	return metrics
+}
+
+// Returns the number of (descending) contributions needed to reach the ratio of the total
+func busFactor(descending []int, total int, ratio float64) int {
+	covered := 0
+	for i, contributions := range descending {
+		covered += contributions
+		if float64(covered) >= ratio*float64(total) {
+			return i + 1
+		}
+	}
+	return len(descending)
+}
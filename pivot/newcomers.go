@@ -0,0 +1,124 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import (
+	"sort"
+	"time"
+)
+
+// Newcomer is a user whose first contribution is in a given period
+type Newcomer struct {
+	User string
+	// FirstMonth is the month of the first contribution
+	FirstMonth time.Time
+	// LastMonth is the month of the last contribution
+	LastMonth time.Time
+	// Total is the number of contributions since the first month
+	Total int
+	// ActiveMonths is the number of months with at least one contribution
+	ActiveMonths int
+	// first is the column of the first contribution
+	first int
+}
+
+// Retention is the share of a group of newcomers still active some months after their first contribution
+type Retention struct {
+	// After is the number of months after the first contribution
+	After int
+	// Newcomers is the number of newcomers whose first contribution is at least "After" months old
+	Newcomers int
+	// Retained is the number of these newcomers with a contribution in the 3 months ending "After"
+	// months after their first contribution
+	Retained int
+}
+
+// Rate returns the share of retained newcomers (0 if there isn't any newcomer)
+func (r Retention) Rate() float64 {
+	if r.Newcomers == 0 {
+		return 0
+	}
+	return float64(r.Retained) / float64(r.Newcomers)
+}
+
+// Newcomers returns the users whose first contribution is between two columns (both included),
+// sorted by first month, then by descending total, then by user.
+// The first contributions can't be detected in the first column of the table: it is ignored.
+func (t *PivotTable) Newcomers(from int, to int) []Newcomer {
+	if from < 1 {
+		from = 1
+	}
+	var newcomers []Newcomer
+	for row, user := range t.users {
+		newcomer := Newcomer{User: user, first: -1}
+		for column, value := range t.values[row] {
+			if value == 0 {
+				continue
+			}
+			if newcomer.first == -1 {
+				newcomer.first = column
+				newcomer.FirstMonth = t.months[column]
+			}
+			newcomer.LastMonth = t.months[column]
+			newcomer.Total += value
+			newcomer.ActiveMonths++
+		}
+		if newcomer.first >= from && newcomer.first <= to {
+			newcomers = append(newcomers, newcomer)
+		}
+	}
+
+	sort.Slice(newcomers, func(i, j int) bool {
+		if newcomers[i].first != newcomers[j].first {
+			return newcomers[i].first < newcomers[j].first
+		}
+		if newcomers[i].Total != newcomers[j].Total {
+			return newcomers[i].Total > newcomers[j].Total
+		}
+		return newcomers[i].User < newcomers[j].User
+	})
+	return newcomers
+}
+
+// Retention computes the share of the newcomers still active "after" months after their first
+// contribution: with a contribution in the 3 months ending "after" months after the first one.
+// The newcomers whose first contribution is too recent are not counted.
+func (t *PivotTable) Retention(newcomers []Newcomer, after int) Retention {
+	retention := Retention{After: after}
+	for _, newcomer := range newcomers {
+		end := newcomer.first + after
+		if end >= len(t.months) {
+			continue
+		}
+		retention.Newcomers++
+
+		start := end - 2
+		if start <= newcomer.first {
+			start = newcomer.first + 1
+		}
+		row := t.UserIndex(newcomer.User)
+		if start <= end && t.Sum(row, start, end) > 0 {
+			retention.Retained++
+		}
+	}
+	return retention
+}
@@ -0,0 +1,139 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// StdinName is the file name standing for the standard input
+const StdinName = "-"
+
+// Compression formats of the pivot table files
+const (
+	compressionNone = ""
+	compressionGzip = "gzip"
+	compressionZstd = "zstd"
+)
+
+// Magic bytes at the start of the compressed data
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// SourceName returns the name of the source of a pivot table loaded from the given file
+func SourceName(fileName string) string {
+	if fileName == StdinName {
+		return "stdin"
+	}
+	return fileName
+}
+
+// Open opens a pivot table file for reading. The "-" file name stands for the standard input.
+// Gzip and zstd files are transparently decompressed: the compression is detected with the
+// magic bytes of the data or, failing that, with the ".gz" or ".zst" extension of the file.
+func Open(fileName string) (io.ReadCloser, error) {
+	var f io.ReadCloser = io.NopCloser(os.Stdin)
+	if fileName != StdinName {
+		file, err := os.Open(fileName)
+		if err != nil {
+			return nil, err
+		}
+		f = file
+	}
+
+	r, err := decompress(f, compressionOfExtension(fileName))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &compressedFile{ReadCloser: r, file: f}, nil
+}
+
+// Decompress returns a reader decompressing the gzip or zstd data (detected with their magic bytes).
+// Uncompressed data is returned as is.
+func Decompress(r io.Reader) (io.ReadCloser, error) {
+	return decompress(r, compressionNone)
+}
+
+// Decompresses the data, using the expected compression if the magic bytes don't identify it
+func decompress(r io.Reader, expected string) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+	// An error means that the data is too short to be compressed: it is then read as is
+	header, _ := buffered.Peek(len(zstdMagic))
+
+	compression := expected
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		compression = compressionGzip
+	case bytes.HasPrefix(header, zstdMagic):
+		compression = compressionZstd
+	}
+
+	switch compression {
+	case compressionGzip:
+		return gzip.NewReader(buffered)
+	case compressionZstd:
+		decoder, err := zstd.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return io.NopCloser(buffered), nil
+	}
+}
+
+// Returns the compression format matching the file extension
+func compressionOfExtension(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".gz", ".gzip":
+		return compressionGzip
+	case ".zst", ".zstd":
+		return compressionZstd
+	default:
+		return compressionNone
+	}
+}
+
+// Closes both the decompressor and the underlying file
+type compressedFile struct {
+	io.ReadCloser
+	file io.Closer
+}
+
+func (c *compressedFile) Close() error {
+	err := c.ReadCloser.Close()
+	if fileErr := c.file.Close(); err == nil {
+		err = fileErr
+	}
+	return err
+}
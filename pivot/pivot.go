@@ -0,0 +1,323 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package pivot holds the typed model of the contribution pivot tables
+// (users x months) as generated by the GNU "datamash" pivot function.
+//
+// A table is loaded and validated once and can then be queried by user and by
+// month without having to parse the raw CSV cells again.
+package pivot
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// MonthLayout is the format of the month column headers ("YYYY-MM")
+const MonthLayout = "2006-01"
+
+// DeletedUser is the login GitHub reports for accounts that no longer exist
+const DeletedUser = "deleted_user"
+
+var monthRegexp = regexp.MustCompile(`^20[0-9]{2}-[0-9]{2}$`)
+
+// PivotTable is a contribution pivot table: one row per user, one column per month.
+type PivotTable struct {
+	// Source is the name of the file the table was loaded from (empty if built in memory)
+	Source string
+
+	months []time.Time
+	users  []string
+	values [][]int
+
+	userIndex  map[string]int
+	monthIndex map[time.Time]int
+
+	// details are the contributions the table was built from (long-format input only)
+	details *LongTable
+}
+
+// Total is the accumulated number of contributions of a user over a period
+type Total struct {
+	User  string
+	Total int
+}
+
+// New builds a pivot table from its months, users and values (indexed as values[user][month]).
+// The months must be in chronological order. They don't need to be contiguous.
+// The months are copied (normalized to the start of the month). The users and the values
+// are used as is and must not be modified afterwards.
+func New(months []time.Time, users []string, values [][]int) (*PivotTable, error) {
+	if len(values) != len(users) {
+		return nil, fmt.Errorf("%d users but %d rows of values", len(users), len(values))
+	}
+
+	t := &PivotTable{
+		months:     make([]time.Time, len(months)),
+		users:      users,
+		values:     values,
+		userIndex:  make(map[string]int, len(users)),
+		monthIndex: make(map[time.Time]int, len(months)),
+	}
+
+	for i, month := range months {
+		month = StartOfMonth(month)
+		if _, found := t.monthIndex[month]; found {
+			return nil, fmt.Errorf("month %s is defined more than once", FormatMonth(month))
+		}
+		if i > 0 && month.Before(t.months[i-1]) {
+			return nil, fmt.Errorf("month %s is not in chronological order (follows %s)", FormatMonth(month), FormatMonth(t.months[i-1]))
+		}
+		t.months[i] = month
+		t.monthIndex[month] = i
+	}
+
+	for i, user := range users {
+		if _, found := t.userIndex[user]; found {
+			return nil, fmt.Errorf("user \"%s\" is defined more than once", user)
+		}
+		if len(values[i]) != len(months) {
+			return nil, fmt.Errorf("user \"%s\" has %d values while expecting %d", user, len(values[i]), len(months))
+		}
+		t.userIndex[user] = i
+	}
+
+	return t, nil
+}
+
+// Details returns the detailed contributions the table was built from when it was read
+// from a long-format table (see LongTable), nil otherwise. The tables derived from it with
+// aliases, exclusions or pseudonyms keep the details, whose logins are those of the input.
+func (t *PivotTable) Details() *LongTable {
+	return t.details
+}
+
+// NbrOfMonths returns the number of month columns
+func (t *PivotTable) NbrOfMonths() int {
+	return len(t.months)
+}
+
+// NbrOfUsers returns the number of user rows
+func (t *PivotTable) NbrOfUsers() int {
+	return len(t.users)
+}
+
+// Month returns the month of the given column
+func (t *PivotTable) Month(index int) time.Time {
+	return t.months[index]
+}
+
+// Months returns a copy of the month columns, in table order
+func (t *PivotTable) Months() []time.Time {
+	return append([]time.Time(nil), t.months...)
+}
+
+// MonthLabels returns the month columns formatted as "YYYY-MM"
+func (t *PivotTable) MonthLabels() []string {
+	labels := make([]string, len(t.months))
+	for i, month := range t.months {
+		labels[i] = FormatMonth(month)
+	}
+	return labels
+}
+
+// MonthIndex returns the column of the given month, or -1 if it is not in the table
+func (t *PivotTable) MonthIndex(month time.Time) int {
+	if index, found := t.monthIndex[StartOfMonth(month)]; found {
+		return index
+	}
+	return -1
+}
+
+// ColumnsBetween returns the first and last columns whose month lies between two calendar months (both included).
+// Both columns are -1 if no month of the table falls in the interval.
+func (t *PivotTable) ColumnsBetween(from time.Time, to time.Time) (first int, last int) {
+	from, to = StartOfMonth(from), StartOfMonth(to)
+	first = sort.Search(len(t.months), func(i int) bool { return !t.months[i].Before(from) })
+	last = sort.Search(len(t.months), func(i int) bool { return t.months[i].After(to) }) - 1
+	if first > last {
+		return -1, -1
+	}
+	return first, last
+}
+
+// IsContiguous is true if there is no missing month between the first and the last column
+func (t *PivotTable) IsContiguous() bool {
+	if len(t.months) == 0 {
+		return true
+	}
+	return MonthsBetween(t.months[0], t.months[len(t.months)-1]) == len(t.months)-1
+}
+
+// User returns the login of the given row
+func (t *PivotTable) User(index int) string {
+	return t.users[index]
+}
+
+// Users returns a copy of the user logins, in table order
+func (t *PivotTable) Users() []string {
+	return append([]string(nil), t.users...)
+}
+
+// UserIndex returns the row of the given user, or -1 if it is not in the table
+func (t *PivotTable) UserIndex(user string) int {
+	if index, found := t.userIndex[user]; found {
+		return index
+	}
+	return -1
+}
+
+// Value returns the number of contributions for a given row and column
+func (t *PivotTable) Value(user int, month int) int {
+	return t.values[user][month]
+}
+
+// Row returns a copy of all the values of the given row
+func (t *PivotTable) Row(user int) []int {
+	return append([]int(nil), t.values[user]...)
+}
+
+// Lookup returns the number of contributions of a user for a given month.
+// The boolean is false if either the user or the month is not in the table.
+func (t *PivotTable) Lookup(user string, month time.Time) (int, bool) {
+	userIndex := t.UserIndex(user)
+	monthIndex := t.MonthIndex(month)
+	if userIndex == -1 || monthIndex == -1 {
+		return 0, false
+	}
+	return t.values[userIndex][monthIndex], true
+}
+
+// Sum returns the contributions of a row between two columns (both included)
+func (t *PivotTable) Sum(user int, from int, to int) int {
+	total := 0
+	for i := from; i <= to; i++ {
+		total += t.values[user][i]
+	}
+	return total
+}
+
+// Totals returns, for every user, the sum of the contributions between two columns (both included).
+// The result is sorted by descending total, then by login (regardless of the case).
+func (t *PivotTable) Totals(from int, to int) []Total {
+	totals := make([]Total, len(t.users))
+	for i, user := range t.users {
+		totals[i] = Total{User: user, Total: t.Sum(i, from, to)}
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Total != totals[j].Total {
+			return totals[i].Total > totals[j].Total
+		}
+		if login, other := loginKey(totals[i].User), loginKey(totals[j].User); login != other {
+			return login < other
+		}
+		return totals[i].User < totals[j].User
+	})
+	return totals
+}
+
+// Top returns the first topSize entries of a sorted list of totals.
+// Users with the same total as the last selected entry ("ex aequo") are included too.
+func Top(totals []Total, topSize int) []Total {
+	if topSize >= len(totals) {
+		return totals
+	}
+	if topSize <= 0 {
+		return nil
+	}
+	end := topSize
+	for end < len(totals) && totals[end].Total == totals[topSize-1].Total {
+		end++
+	}
+	return totals[:end]
+}
+
+// Select returns a new table containing only the given users, in the given order
+func (t *PivotTable) Select(users []string) (*PivotTable, error) {
+	var values [][]int
+	for _, user := range users {
+		index := t.UserIndex(user)
+		if index == -1 {
+			return nil, fmt.Errorf("user \"%s\" is not in the table", user)
+		}
+		values = append(values, t.Row(index))
+	}
+	selected, err := New(t.Months(), append([]string(nil), users...), values)
+	if err != nil {
+		return nil, err
+	}
+	selected.Source = t.Source
+	return selected, nil
+}
+
+// Records returns the table as CSV records (header included), as datamash would output it
+func (t *PivotTable) Records() [][]string {
+	records := make([][]string, 0, len(t.users)+1)
+
+	header := append([]string{""}, t.MonthLabels()...)
+	records = append(records, header)
+
+	for i, user := range t.users {
+		record := make([]string, 0, len(t.months)+1)
+		record = append(record, user)
+		for _, value := range t.values[i] {
+			record = append(record, strconv.Itoa(value))
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// ParseMonth parses a "YYYY-MM" month header
+func ParseMonth(month string) (time.Time, error) {
+	if !monthRegexp.MatchString(month) {
+		return time.Time{}, fmt.Errorf("\"%s\" is not of the expected format (YYYY-MM)", month)
+	}
+	parsed, err := time.Parse(MonthLayout, month)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("\"%s\" is not a valid month", month)
+	}
+	return parsed, nil
+}
+
+// FormatMonth formats a month as "YYYY-MM"
+func FormatMonth(month time.Time) string {
+	return month.Format(MonthLayout)
+}
+
+// StartOfMonth truncates a date to the first day of its month (UTC)
+func StartOfMonth(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// AddMonths adds (or subtracts) a number of calendar months to a month
+func AddMonths(month time.Time, nbrOfMonths int) time.Time {
+	return StartOfMonth(month).AddDate(0, nbrOfMonths, 0)
+}
+
+// MonthsBetween returns the number of calendar months from one month to another
+func MonthsBetween(from time.Time, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
@@ -0,0 +1,1168 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+var testRecords = [][]string{
+	{"", "2022-11", "2022-12", "2023-01", "2023-02"},
+	{"basil", "10", "1", "2", "3"},
+	{"MarkEWaite", "4", "5", "6", "7"},
+	{"lemeurherve", "0", "0", "1", "0"},
+	{"daniel-beck", "1", "1", "1", "1"},
+	{"deleted_user", "2", "0", "1", "0"},
+}
+
+func Test_FromRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		records [][]string
+		wantErr string
+	}{
+		{
+			"Happy case",
+			testRecords,
+			"",
+		},
+		{
+			"Bad first column",
+			[][]string{{"submitter", "2022-11"}, {"basil", "1"}},
+			"line 1, column 1: Not the expected first column name (should be empty)",
+		},
+		{
+			"Bad date column",
+			[][]string{{"", "2022-11", "junk"}, {"basil", "1", "2"}},
+			"line 1, column 3: Column header junk is not of the expected format (YYYY-MM)",
+		},
+		{
+			"Invalid month number",
+			[][]string{{"", "2022-11", "2022-13"}, {"basil", "1", "2"}},
+			"line 1, column 3: Column header 2022-13 is not of the expected format (YYYY-MM)",
+		},
+		{
+			"Bad submitter name",
+			[][]string{{"", "2022-11", "2022-12"}, {"basil", "1", "2"}, {"bas il", "1", "2"}},
+			"line 3, column 1: User \"bas il\" does not follow GitHub rules",
+		},
+		{
+			"Non integer value",
+			[][]string{{"", "2022-11", "2022-12"}, {"basil", "1", "x"}},
+			"line 2, column 3: Value \"x\" isn't an integer",
+		},
+		{
+			"Negative value",
+			[][]string{{"", "2022-11", "2022-12"}, {"basil", "-1", "2"}},
+			"line 2, column 2: Value \"-1\" is negative",
+		},
+		{
+			"Ragged row",
+			[][]string{{"", "2022-11", "2022-12"}, {"basil", "1"}},
+			"line 2: Line has 2 columns while the header defines 3",
+		},
+		{
+			"Duplicate user",
+			[][]string{{"", "2022-11", "2022-12"}, {"basil", "1", "2"}, {"basil", "1", "2"}},
+			"line 3, column 1: User \"basil\" is already defined at line 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := FromRecords(tt.records)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.NotNil(t, table)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_CheckRecords_collectsAllIssues(t *testing.T) {
+	records := [][]string{
+		{"submitter", "2022-11", "junk"},
+		{"basil", "1", "x"},
+		{"il--ya", "1", "2"},
+		{"bas il", "-1", "2"},
+		{"daniel-beck", "1"},
+	}
+
+	table, report := CheckRecords(records)
+	assert.Nil(t, table, "No table expected when errors are found")
+	assert.Equal(t, 6, report.NbrOfErrors())
+	assert.Equal(t, 1, report.NbrOfWarnings())
+
+	expected := []Issue{
+		{1, 1, SeverityError, RuleHeader, "Not the expected first column name (should be empty)"},
+		{1, 3, SeverityError, RuleMonthFormat, "Column header junk is not of the expected format (YYYY-MM)"},
+		{2, 3, SeverityError, RuleValue, "Value \"x\" isn't an integer"},
+		{3, 1, SeverityWarning, RuleUserConvention, "User \"il--ya\" does not follow the strict GitHub naming convention"},
+		{4, 1, SeverityError, RuleUser, "User \"bas il\" does not follow GitHub rules"},
+		{4, 2, SeverityError, RuleNegativeValue, "Value \"-1\" is negative"},
+		{5, 0, SeverityError, RuleRowLength, "Line has 2 columns while the header defines 3"},
+	}
+	assert.Equal(t, expected, report.Issues)
+}
+
+func Test_CheckRecords_warningsOnly(t *testing.T) {
+	records := [][]string{
+		{"", "2022-11", "2022-12"},
+		{"basil", "1", "2"},
+		{"tobias-", "1", "2"},
+		{"dependabot[bot]", "1", "2"},
+	}
+
+	table, report := CheckRecords(records)
+	assert.NotNil(t, table, "Warnings must not prevent the table from being loaded")
+	assert.False(t, report.HasErrors())
+	assert.Equal(t, 1, report.NbrOfWarnings())
+}
+
+func Test_CheckRecords_monthSequence(t *testing.T) {
+	records := [][]string{
+		{"", "2022-11", "2022-12", "2022-12", "2022-10", "2023-03", "2023-05"},
+		{"basil", "1", "2", "3", "4", "5", "6"},
+	}
+
+	table, report := CheckRecords(records)
+	assert.Nil(t, table)
+
+	expected := []Issue{
+		{1, 4, SeverityError, RuleDuplicateMonth, "Month 2022-12 is already defined in column 3"},
+		{1, 5, SeverityError, RuleMonthOrder, "Month 2022-10 is not in chronological order (follows 2022-12)"},
+		{1, 6, SeverityWarning, RuleMonthGap, "Months 2023-01 to 2023-02 are missing (filled with 0)"},
+		{1, 7, SeverityWarning, RuleMonthGap, "Month 2023-04 is missing (filled with 0)"},
+	}
+	assert.Equal(t, expected, report.Issues)
+}
+
+func Test_CheckRecords_fillsMonthGaps(t *testing.T) {
+	records := [][]string{
+		{"", "2022-11", "2023-02", "2023-03"},
+		{"basil", "1", "2", "3"},
+		{"MarkEWaite", "4", "5", "6"},
+	}
+
+	table, report := CheckRecords(records)
+	assert.False(t, report.HasErrors(), "Gaps must not prevent the table from being loaded")
+	assert.Equal(t, 1, report.NbrOfWarnings())
+	assert.True(t, table.IsContiguous())
+
+	expected := [][]string{
+		{"", "2022-11", "2022-12", "2023-01", "2023-02", "2023-03"},
+		{"basil", "1", "0", "0", "2", "3"},
+		{"MarkEWaite", "4", "0", "0", "5", "6"},
+	}
+	assert.Equal(t, expected, table.Records())
+}
+
+func Test_New_monthOrder(t *testing.T) {
+	november, _ := ParseMonth("2022-11")
+	january, _ := ParseMonth("2023-01")
+
+	_, err := New([]time.Time{january, november}, []string{"basil"}, [][]int{{1, 2}})
+	assert.EqualError(t, err, "month 2022-11 is not in chronological order (follows 2023-01)")
+
+	table, err := New([]time.Time{november, january}, []string{"basil"}, [][]int{{1, 2}})
+	assert.NoError(t, err)
+	assert.False(t, table.IsContiguous())
+}
+
+func Test_New_copiesMonths(t *testing.T) {
+	midNovember := time.Date(2022, time.November, 15, 10, 0, 0, 0, time.UTC)
+	months := []time.Time{midNovember}
+
+	table, err := New(months, []string{"basil"}, [][]int{{1}})
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-11", FormatMonth(table.Month(0)))
+	assert.Equal(t, 1, table.Month(0).Day(), "The months of the table start at the first day")
+	assert.Equal(t, midNovember, months[0], "The months of the caller must not be modified")
+}
+
+func Test_ColumnsBetween(t *testing.T) {
+	months := []time.Time{}
+	for _, month := range []string{"2022-11", "2023-01", "2023-02", "2023-05"} {
+		parsed, _ := ParseMonth(month)
+		months = append(months, parsed)
+	}
+	table, err := New(months, []string{"basil"}, [][]int{{1, 2, 3, 4}})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		from      string
+		to        string
+		wantFirst int
+		wantLast  int
+	}{
+		{"Whole table", "2022-11", "2023-05", 0, 3},
+		{"Wider than the table", "2020-01", "2024-01", 0, 3},
+		{"Bounds in gaps", "2022-12", "2023-04", 1, 2},
+		{"Single month", "2023-01", "2023-01", 1, 1},
+		{"Only a gap", "2023-03", "2023-04", -1, -1},
+		{"After the table", "2023-06", "2023-08", -1, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, _ := ParseMonth(tt.from)
+			to, _ := ParseMonth(tt.to)
+			gotFirst, gotLast := table.ColumnsBetween(from, to)
+			assert.Equal(t, tt.wantFirst, gotFirst)
+			assert.Equal(t, tt.wantLast, gotLast)
+		})
+	}
+}
+
+func Test_CheckFile_csvError(t *testing.T) {
+	table, report := CheckFile("unexistantFile.csv")
+	assert.Nil(t, table)
+	assert.True(t, report.HasErrors())
+	assert.Equal(t, "unexistantFile.csv", report.Source)
+}
+
+func Test_Load(t *testing.T) {
+	table, err := Load("../test_data/short_overview.csv")
+	assert.NoError(t, err, "Unexpected load error")
+	assert.Equal(t, "../test_data/short_overview.csv", table.Source)
+	assert.Equal(t, 40, table.NbrOfMonths())
+	assert.Equal(t, 138, table.NbrOfUsers())
+	assert.Equal(t, "2020-01", FormatMonth(table.Month(0)))
+	assert.Equal(t, "2023-04", FormatMonth(table.Month(39)))
+
+	_, err = Load("../test_data/bad_data_value.csv")
+	assert.Error(t, err, "Load should have failed")
+
+	_, err = Load("unexistantFile.csv")
+	assert.Error(t, err, "Load should have failed")
+}
+
+// Writes the content of a file compressed with the given format in a temporary directory
+func compressTestFile(t *testing.T, sourceFileName string, targetFileName string, compression string) string {
+	data, err := os.ReadFile(sourceFileName)
+	assert.NoError(t, err)
+
+	var buffer bytes.Buffer
+	switch compression {
+	case compressionGzip:
+		w := gzip.NewWriter(&buffer)
+		_, err = w.Write(data)
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+	case compressionZstd:
+		w, err := zstd.NewWriter(&buffer)
+		assert.NoError(t, err)
+		_, err = w.Write(data)
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+	default:
+		buffer.Write(data)
+	}
+
+	fileName := filepath.Join(t.TempDir(), targetFileName)
+	assert.NoError(t, os.WriteFile(fileName, buffer.Bytes(), 0644))
+	return fileName
+}
+
+func Test_Load_compressed(t *testing.T) {
+	tests := []struct {
+		name        string
+		fileName    string
+		compression string
+	}{
+		{"gzip", "overview.csv.gz", compressionGzip},
+		{"zstd", "overview.csv.zst", compressionZstd},
+		{"gzip detected without extension", "overview.csv", compressionGzip},
+		{"zstd detected without extension", "overview.bin", compressionZstd},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileName := compressTestFile(t, "../test_data/short_overview.csv", tt.fileName, tt.compression)
+
+			table, err := Load(fileName)
+			assert.NoError(t, err, "Unexpected load error")
+			assert.Equal(t, 40, table.NbrOfMonths())
+			assert.Equal(t, 138, table.NbrOfUsers())
+		})
+	}
+}
+
+func Test_Load_notCompressed(t *testing.T) {
+	// The extension announces a compressed file that isn't
+	fileName := compressTestFile(t, "../test_data/short_overview.csv", "overview.csv.gz", compressionNone)
+
+	_, err := Load(fileName)
+	assert.ErrorContains(t, err, "gzip: invalid header")
+}
+
+func Test_Decompress(t *testing.T) {
+	var buffer bytes.Buffer
+	w := gzip.NewWriter(&buffer)
+	_, err := w.Write([]byte(",2023-01\nalpha,1\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	for _, data := range [][]byte{buffer.Bytes(), []byte(",2023-01\nalpha,1\n"), []byte("")} {
+		r, err := Decompress(bytes.NewReader(data))
+		assert.NoError(t, err)
+		content, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.NoError(t, r.Close())
+		if len(data) > 0 {
+			assert.Equal(t, ",2023-01\nalpha,1\n", string(content))
+		} else {
+			assert.Empty(t, content)
+		}
+	}
+}
+
+func Test_SourceName(t *testing.T) {
+	assert.Equal(t, "stdin", SourceName(StdinName))
+	assert.Equal(t, "overview.csv", SourceName("overview.csv"))
+}
+
+func Test_UserIndex(t *testing.T) {
+	table, err := FromRecords(testRecords)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		user      string
+		wantIndex int
+	}{
+		{"Happy case - 1", "basil", 0},
+		{"Happy case - 2", "lemeurherve", 2},
+		{"Happy case - 3", "deleted_user", 4},
+		{"notfound", "jmm", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotIndex := table.UserIndex(tt.user); gotIndex != tt.wantIndex {
+				t.Errorf("UserIndex() = %v, want %v", gotIndex, tt.wantIndex)
+			}
+		})
+	}
+}
+
+func Test_Lookup(t *testing.T) {
+	table, err := FromRecords(testRecords)
+	assert.NoError(t, err)
+
+	value, found := table.Lookup("MarkEWaite", time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC))
+	assert.True(t, found)
+	assert.Equal(t, 6, value)
+
+	// Any day of the month can be used
+	value, found = table.Lookup("MarkEWaite", time.Date(2023, time.January, 17, 12, 0, 0, 0, time.UTC))
+	assert.True(t, found)
+	assert.Equal(t, 6, value)
+
+	_, found = table.Lookup("MarkEWaite", time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC))
+	assert.False(t, found)
+
+	_, found = table.Lookup("jmm", time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC))
+	assert.False(t, found)
+}
+
+func Test_Totals_and_Top(t *testing.T) {
+	table, err := FromRecords(testRecords)
+	assert.NoError(t, err)
+
+	totals := table.Totals(1, 3)
+	assert.Equal(t, Total{"MarkEWaite", 18}, totals[0])
+	assert.Equal(t, Total{"basil", 6}, totals[1])
+	assert.Equal(t, Total{"daniel-beck", 3}, totals[2])
+
+	// "lemeurherve" and "deleted_user" are ex aequo: they are sorted by login
+	assert.Equal(t, "deleted_user", totals[3].User)
+	assert.Equal(t, "lemeurherve", totals[4].User)
+	assert.Len(t, Top(totals, 4), 5)
+	assert.Len(t, Top(totals, 2), 2)
+	assert.Len(t, Top(totals, 10), 5)
+	assert.Len(t, Top(totals, 0), 0)
+}
+
+func Test_Select(t *testing.T) {
+	table, err := FromRecords(testRecords)
+	assert.NoError(t, err)
+
+	selected, err := table.Select([]string{"daniel-beck", "basil"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"daniel-beck", "basil"}, selected.Users())
+	assert.Equal(t, []int{10, 1, 2, 3}, selected.Row(1))
+
+	_, err = table.Select([]string{"unknownUser"})
+	assert.EqualError(t, err, "user \"unknownUser\" is not in the table")
+}
+
+func Test_Write(t *testing.T) {
+	table, err := FromRecords(testRecords)
+	assert.NoError(t, err)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, table.Write(&buffer))
+
+	reloaded, err := Read(&buffer)
+	assert.NoError(t, err)
+	if !reflect.DeepEqual(reloaded.Records(), testRecords) {
+		t.Errorf("Write() round trip = %v, want %v", reloaded.Records(), testRecords)
+	}
+}
+
+func Test_monthArithmetic(t *testing.T) {
+	month, err := ParseMonth("2023-02")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "2022-03", FormatMonth(AddMonths(month, -11)))
+	assert.Equal(t, "2024-01", FormatMonth(AddMonths(month, 11)))
+	assert.Equal(t, 11, MonthsBetween(AddMonths(month, -11), month))
+	assert.Equal(t, -1, MonthsBetween(month, AddMonths(month, -1)))
+}
+
+func Test_RepairRecords(t *testing.T) {
+	records := [][]string{
+		{"", "2022-12", "2022-10", " 2023-01", "2022-10"},
+		{"basil", "1", "", "2", "1"},
+		{"MarkEWaite ", "4", "5", "6", "0"},
+		{"basil", "1", "1", "1", "0"},
+	}
+
+	table, report := RepairRecords(records)
+	assert.False(t, report.HasErrors(), "Repaired table should be valid")
+	assert.NotNil(t, table)
+
+	expected := [][]string{
+		{"", "2022-10", "2022-11", "2022-12", "2023-01"},
+		{"basil", "2", "0", "2", "3"},
+		{"MarkEWaite", "5", "0", "4", "6"},
+	}
+	assert.Equal(t, expected, table.Records())
+
+	expectedFixes := []Fix{
+		{1, 4, "Trimmed whitespace around \"2023-01\""},
+		{2, 3, "Replaced blank value by 0"},
+		{3, 1, "Trimmed whitespace around \"MarkEWaite\""},
+		{1, 0, "Sorted the month columns chronologically"},
+		{1, 5, "Summed duplicate month 2022-10 into column 3"},
+		{1, 0, "Inserted missing month 2022-11 (filled with 0)"},
+		{4, 1, "Summed duplicate user \"basil\" into line 2"},
+	}
+	assert.Equal(t, expectedFixes, report.Fixes)
+}
+
+func Test_RepairRecords_notRepairable(t *testing.T) {
+	records := [][]string{
+		{"", "2022-12", "junk"},
+		{"basil", "1", "x"},
+	}
+
+	table, report := RepairRecords(records)
+	assert.Nil(t, table)
+	assert.Equal(t, 2, report.NbrOfErrors())
+}
+
+func Test_Merge(t *testing.T) {
+	jenkinsci, err := FromRecords([][]string{
+		{"", "2022-11", "2022-12", "2023-01"},
+		{"basil", "1", "2", "3"},
+		{"MarkEWaite", "4", "5", "6"},
+	})
+	assert.NoError(t, err)
+	jenkinsInfra, err := FromRecords([][]string{
+		{"", "2023-01", "2023-02", "2023-04"},
+		{"lemeurherve", "1", "1", "1"},
+		{"basil", "3", "0", "2"},
+	})
+	assert.NoError(t, err)
+
+	merged, conflicts, err := Merge(jenkinsci, jenkinsInfra)
+	assert.NoError(t, err)
+
+	expected := [][]string{
+		{"", "2022-11", "2022-12", "2023-01", "2023-02", "2023-03", "2023-04"},
+		{"basil", "1", "2", "6", "0", "0", "2"},
+		{"MarkEWaite", "4", "5", "6", "0", "0", "0"},
+		{"lemeurherve", "0", "0", "1", "1", "0", "1"},
+	}
+	assert.Equal(t, expected, merged.Records())
+
+	january, _ := ParseMonth("2023-01")
+	expectedConflicts := []Conflict{
+		{Month: january, Sources: []string{"table #1", "table #2"}, Users: []string{"MarkEWaite", "lemeurherve"}},
+	}
+	assert.Equal(t, expectedConflicts, conflicts)
+
+	_, _, err = Merge()
+	assert.Error(t, err)
+}
+
+func Test_ReadEvents(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     string
+		content    string
+		wantEvents int
+		wantErr    string
+	}{
+		{
+			"CSV with columns in any order",
+			EventFormatCSV,
+			"created_at,user,repo\n2023-01-31T23:30:00Z,basil,jenkinsci/jenkins\n2023-02-01,MarkEWaite,jenkinsci/git-plugin\n",
+			2,
+			"",
+		},
+		{
+			"CSV without repo",
+			EventFormatCSV,
+			"user,created_at\nbasil,2023-01-31 23:30:00\n",
+			1,
+			"",
+		},
+		{
+			"CSV without user",
+			EventFormatCSV,
+			"login,created_at\nbasil,2023-01-31T23:30:00Z\n",
+			0,
+			"line 1: Missing \"user\" column",
+		},
+		{
+			"CSV with invalid date",
+			EventFormatCSV,
+			"user,repo,created_at\nbasil,jenkinsci/jenkins,yesterday\n",
+			0,
+			"line 2, column 3: \"yesterday\" is not a valid date (expecting RFC 3339, e.g. 2023-01-31T12:00:00Z)",
+		},
+		{
+			"JSON Lines with empty line",
+			EventFormatJSONL,
+			"{\"user\": \"basil\", \"repo\": \"jenkinsci/jenkins\", \"created_at\": \"2023-01-31T23:30:00Z\"}\n\n{\"user\": \"MarkEWaite\", \"created_at\": \"2023-02-01T00:00:00Z\"}\n",
+			2,
+			"",
+		},
+		{
+			"JSON Lines with invalid user",
+			EventFormatJSONL,
+			"{\"user\": \"bas il\", \"created_at\": \"2023-01-31T23:30:00Z\"}\n",
+			0,
+			"line 1: User \"bas il\" does not follow GitHub rules",
+		},
+		{
+			"Unknown format",
+			"xml",
+			"",
+			0,
+			"Unknown event format \"xml\"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := ReadEvents(strings.NewReader(tt.content), tt.format)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, events, tt.wantEvents)
+		})
+	}
+}
+
+func Test_FromEvents(t *testing.T) {
+	events, err := ReadEvents(strings.NewReader(`user,repo,created_at
+basil,jenkinsci/jenkins,2022-11-03T10:00:00Z
+basil,jenkinsci/remoting,2023-01-31T23:30:00Z
+MarkEWaite,jenkinsci/git-plugin,2022-11-15T08:12:00Z
+`), EventFormatCSV)
+	assert.NoError(t, err)
+
+	table, err := FromEvents(events, nil)
+	assert.NoError(t, err)
+	expected := [][]string{
+		{"", "2022-11", "2022-12", "2023-01"},
+		{"MarkEWaite", "1", "0", "0"},
+		{"basil", "1", "0", "1"},
+	}
+	assert.Equal(t, expected, table.Records())
+
+	// The last event belongs to February in Brussels
+	brussels, err := time.LoadLocation("Europe/Brussels")
+	assert.NoError(t, err)
+	table, err = FromEvents(events, brussels)
+	assert.NoError(t, err)
+	expected = [][]string{
+		{"", "2022-11", "2022-12", "2023-01", "2023-02"},
+		{"MarkEWaite", "1", "0", "0", "0"},
+		{"basil", "1", "0", "0", "1"},
+	}
+	assert.Equal(t, expected, table.Records())
+
+	_, err = FromEvents(nil, nil)
+	assert.Error(t, err)
+}
+
+func Test_CombinedScores(t *testing.T) {
+	submitters, err := FromRecords([][]string{
+		{"", "2022-11", "2022-12", "2023-01"},
+		{"basil", "1", "2", "3"},
+		{"MarkEWaite", "4", "0", "1"},
+	})
+	assert.NoError(t, err)
+	commenters, err := FromRecords([][]string{
+		{"", "2022-12", "2023-01", "2023-02"},
+		{"MarkEWaite", "2", "2", "9"},
+		{"jtnord", "5", "5", "5"},
+	})
+	assert.NoError(t, err)
+
+	from, _ := ParseMonth("2022-12")
+	to, _ := ParseMonth("2023-01")
+	scores := CombinedScores(from, to, Weighted{submitters, 2}, Weighted{commenters, 0.5})
+
+	expected := []Score{
+		{"basil", 10, []int{5, 0}},
+		{"jtnord", 5, []int{0, 10}},
+		{"MarkEWaite", 4, []int{1, 4}},
+	}
+	assert.Equal(t, expected, scores)
+
+	assert.Len(t, TopScores(scores, 2), 2)
+	assert.Len(t, TopScores(scores, 5), 3)
+	assert.Len(t, TopScores(scores, 0), 0)
+}
+
+func Test_ComputeMetrics(t *testing.T) {
+	tests := []struct {
+		name   string
+		totals []int
+		want   Metrics
+	}{
+		{"no activity", []int{0, 0}, Metrics{}},
+		{"single contributor", []int{0, 7}, Metrics{ActiveUsers: 1, Total: 7, BusFactor50: 1, BusFactor80: 1, Gini: 0, Herfindahl: 1}},
+		{"equal contributors", []int{5, 5, 5, 5}, Metrics{ActiveUsers: 4, Total: 20, BusFactor50: 2, BusFactor80: 4, Gini: 0, Herfindahl: 0.25}},
+		{"concentrated", []int{1, 0, 1, 8}, Metrics{ActiveUsers: 3, Total: 10, BusFactor50: 1, BusFactor80: 1, Gini: 14.0 / 30, Herfindahl: 0.66}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeMetrics(tt.totals)
+			assert.Equal(t, tt.want.ActiveUsers, got.ActiveUsers)
+			assert.Equal(t, tt.want.Total, got.Total)
+			assert.Equal(t, tt.want.BusFactor50, got.BusFactor50)
+			assert.Equal(t, tt.want.BusFactor80, got.BusFactor80)
+			assert.InDelta(t, tt.want.Gini, got.Gini, 1e-9)
+			assert.InDelta(t, tt.want.Herfindahl, got.Herfindahl, 1e-9)
+		})
+	}
+}
+
+func Test_RollingMetrics(t *testing.T) {
+	table, err := FromRecords([][]string{
+		{"", "2023-01", "2023-02", "2023-03"},
+		{"alpha", "1", "0", "3"},
+		{"bravo", "1", "2", "0"},
+	})
+	assert.NoError(t, err)
+
+	series := table.RollingMetrics(0, 2, 2)
+
+	// The first month has no complete window
+	assert.Len(t, series, 2)
+	assert.Equal(t, "2023-02", FormatMonth(series[0].Month))
+	assert.Equal(t, "2023-01", FormatMonth(series[0].Start))
+	assert.Equal(t, 2, series[0].ActiveUsers)
+	assert.Equal(t, 4, series[0].Total)
+	assert.Equal(t, "2023-03", FormatMonth(series[1].Month))
+	assert.Equal(t, 5, series[1].Total)
+}
+
+func Test_TopTimeline(t *testing.T) {
+	table, err := FromRecords([][]string{
+		{"", "2023-01", "2023-02", "2023-03", "2023-04"},
+		{"alpha", "5", "0", "0", "0"},
+		{"bravo", "1", "1", "4", "0"},
+		{"charly", "1", "2", "0", "3"},
+		{"delta", "0", "0", "0", "0"},
+	})
+	assert.NoError(t, err)
+
+	timeline := table.TopTimeline(0, 3, 2, 1)
+
+	// The first month has no complete window
+	assert.Len(t, timeline.Months, 3)
+	assert.Equal(t, "2023-02", FormatMonth(timeline.Months[0]))
+	assert.Equal(t, []string{"alpha", "bravo"}, timeline.Users)
+	assert.Equal(t, [][]int{{1, 0, 0}, {0, 1, 1}}, timeline.Ranks)
+
+	memberships := timeline.Memberships()
+	assert.Equal(t, 1, memberships[0].MonthsInTop)
+	assert.Equal(t, "2023-02", FormatMonth(memberships[0].Entries[0]))
+	assert.Equal(t, "2023-03", FormatMonth(memberships[0].Exits[0]))
+	assert.Equal(t, 2, memberships[1].MonthsInTop)
+	assert.Equal(t, 1, memberships[1].BestRank)
+	assert.Len(t, memberships[1].Entries, 1)
+	assert.Empty(t, memberships[1].Exits)
+}
+
+func Test_TopTimeline_cumulativeWithTies(t *testing.T) {
+	table, err := FromRecords([][]string{
+		{"", "2023-01", "2023-02"},
+		{"alpha", "5", "0"},
+		{"charly", "1", "5"},
+		{"bravo", "2", "4"},
+	})
+	assert.NoError(t, err)
+
+	timeline := table.TopTimeline(1, 1, 0, 1)
+
+	// Ex aequo users share the same rank
+	assert.Equal(t, []string{"bravo", "charly"}, timeline.Users)
+	assert.Equal(t, [][]int{{1}, {1}}, timeline.Ranks)
+}
+
+func Test_Newcomers_and_Retention(t *testing.T) {
+	table, err := FromRecords([][]string{
+		{"", "2023-01", "2023-02", "2023-03", "2023-04", "2023-05", "2023-06"},
+		{"alpha", "5", "1", "0", "0", "0", "0"},
+		{"bravo", "0", "1", "0", "0", "2", "0"},
+		{"charly", "0", "4", "0", "0", "0", "0"},
+		{"delta", "0", "0", "0", "4", "1", "0"},
+		{"echo", "0", "0", "0", "0", "0", "0"},
+	})
+	assert.NoError(t, err)
+
+	// The first month is ignored: alpha is not a newcomer
+	newcomers := table.Newcomers(0, 3)
+	assert.Len(t, newcomers, 3)
+	assert.Equal(t, "charly", newcomers[0].User)
+	assert.Equal(t, "bravo", newcomers[1].User)
+	assert.Equal(t, "2023-02", FormatMonth(newcomers[1].FirstMonth))
+	assert.Equal(t, "2023-05", FormatMonth(newcomers[1].LastMonth))
+	assert.Equal(t, 3, newcomers[1].Total)
+	assert.Equal(t, 2, newcomers[1].ActiveMonths)
+	assert.Equal(t, "delta", newcomers[2].User)
+
+	// delta's first contribution is too recent to be counted
+	retention := table.Retention(newcomers, 3)
+	assert.Equal(t, Retention{After: 3, Newcomers: 2, Retained: 1}, retention)
+	assert.Equal(t, 0.5, retention.Rate())
+
+	assert.Equal(t, Retention{After: 12}, table.Retention(newcomers, 12))
+	assert.Equal(t, 0.0, Retention{}.Rate())
+}
+
+func Test_sameLogin(t *testing.T) {
+	assert.True(t, sameLogin("MarkEWaite", "markewaite"))
+	assert.False(t, sameLogin("MarkEWaite", "MarkEWaite2"))
+	assert.Equal(t, loginKey("markewaite"), loginKey("MarkEWaite"))
+	assert.Regexp(t, loginExpression("mark.*"), "MarkEWaite")
+	assert.NotRegexp(t, loginExpression("mark"), "MarkEWaite")
+}
+
+func Test_AccountFilter(t *testing.T) {
+	filter, err := NewAccountFilter(append([]string{"olblak", "/^jenkins-x-/", " "}, BotPatterns...))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		user     string
+		expected bool
+	}{
+		{"olblak", true},
+		{"OlBlak", true},
+		{"olblak2", false},
+		{"jenkins-x-bot-test", true},
+		{"daniel-beck-bot", true},
+		{"dependabot", true},
+		{"github-actions[bot]", true},
+		{"githubbot", false},
+		{"basil", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, filter.Match(tt.user), tt.user)
+	}
+
+	_, err = NewAccountFilter([]string{"/[/"})
+	assert.Error(t, err)
+	assert.True(t, (*AccountFilter)(nil).IsEmpty())
+}
+
+func Test_ReadAccountPatterns(t *testing.T) {
+	patterns, err := ReadAccountPatterns(strings.NewReader("# Bots\nolblak\n\n  *-ci  \n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"olblak", "*-ci"}, patterns)
+}
+
+func Test_Exclude(t *testing.T) {
+	table, err := FromRecords([][]string{
+		{"", "2023-01", "2023-02"},
+		{"alpha", "5", "0"},
+		{"renovate", "1", "5"},
+		{"bravo", "2", "4"},
+	})
+	assert.NoError(t, err)
+	table.Source = "overview.csv"
+	filter, err := NewAccountFilter(BotPatterns)
+	assert.NoError(t, err)
+
+	filtered, excluded, err := table.Exclude(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"renovate"}, excluded)
+	assert.Equal(t, []string{"alpha", "bravo"}, filtered.Users())
+	assert.Equal(t, []int{2, 4}, filtered.Row(1))
+	assert.Equal(t, "overview.csv", filtered.Source)
+
+	// Nothing to exclude: the table is returned as is
+	same, excluded, err := filtered.Exclude(filter)
+	assert.NoError(t, err)
+	assert.Empty(t, excluded)
+	assert.Same(t, filtered, same)
+}
+
+func Test_ValidateAliases(t *testing.T) {
+	january, _ := ParseMonth("2023-01")
+	march, _ := ParseMonth("2023-03")
+
+	tests := []struct {
+		name    string
+		aliases []Alias
+		wantErr bool
+	}{
+		{"valid", []Alias{{Login: "old", Canonical: "new"}, {Login: "work", Canonical: "new", From: january}}, false},
+		{"own alias", []Alias{{Login: "New", Canonical: "new"}}, true},
+		{"chained", []Alias{{Login: "old", Canonical: "new"}, {Login: "new", Canonical: "newer"}}, true},
+		{"reversed validity", []Alias{{Login: "old", Canonical: "new", From: march, To: january}}, true},
+		{"overlapping", []Alias{{Login: "shared", Canonical: "alpha", To: march}, {Login: "shared", Canonical: "bravo", From: january}}, true},
+		{"successive", []Alias{{Login: "shared", Canonical: "alpha", To: january}, {Login: "shared", Canonical: "bravo", From: march}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAliases(tt.aliases)
+			assert.Equal(t, tt.wantErr, err != nil, "ValidateAliases() error = %v", err)
+		})
+	}
+}
+
+func Test_ApplyAliases(t *testing.T) {
+	table, err := FromRecords([][]string{
+		{"", "2023-01", "2023-02", "2023-03"},
+		{"alpha", "5", "0", "1"},
+		{"alpha-old", "2", "0", "0"},
+		{"bravo-work", "1", "3", "4"},
+		{"charly", "1", "1", "1"},
+	})
+	assert.NoError(t, err)
+	table.Source = "overview.csv"
+	february, _ := ParseMonth("2023-02")
+
+	merged, mergedLogins, err := table.ApplyAliases([]Alias{
+		{Login: "ALPHA-OLD", Canonical: "alpha"},
+		{Login: "bravo-work", Canonical: "bravo", From: february},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alpha-old", "bravo-work"}, mergedLogins)
+
+	// The empty alias row is removed, the canonical row is created if needed
+	assert.Equal(t, []string{"alpha", "bravo-work", "charly", "bravo"}, merged.Users())
+	assert.Equal(t, []int{7, 0, 1}, merged.Row(0))
+	assert.Equal(t, []int{1, 0, 0}, merged.Row(1))
+	assert.Equal(t, []int{0, 3, 4}, merged.Row(3))
+	assert.Equal(t, "overview.csv", merged.Source)
+
+	// The original table is not modified
+	assert.Equal(t, []int{5, 0, 1}, table.Row(0))
+
+	same, mergedLogins, err := table.ApplyAliases([]Alias{{Login: "delta", Canonical: "alpha"}})
+	assert.NoError(t, err)
+	assert.Empty(t, mergedLogins)
+	assert.Same(t, table, same)
+}
+
+func Test_CheckAliases(t *testing.T) {
+	table, err := FromRecords(testRecords)
+	assert.NoError(t, err)
+
+	report := &Report{}
+	table.CheckAliases([]Alias{{Login: "markewaite", Canonical: "basil"}, {Login: "unknown", Canonical: "basil"}}, report)
+
+	assert.Equal(t, 1, report.NbrOfWarnings())
+	assert.Equal(t, RuleAliasCollision, report.Issues[0].Rule)
+	assert.Contains(t, report.Issues[0].Message, "\"MarkEWaite\" is an alias of \"basil\"")
+}
+
+func Test_GroupByAffiliation(t *testing.T) {
+	table, err := FromRecords([][]string{
+		{"", "2023-01", "2023-02", "2023-03"},
+		{"alpha", "5", "0", "1"},
+		{"bravo", "2", "3", "4"},
+		{"charly", "1", "1", "1"},
+	})
+	assert.NoError(t, err)
+	table.Source = "overview.csv"
+	february, _ := ParseMonth("2023-02")
+	affiliations := []Affiliation{
+		{Login: "Alpha", Organization: "Acme"},
+		{Login: "bravo", Organization: "Acme", To: february},
+		{Login: "bravo", Organization: "Globex", From: AddMonths(february, 1)},
+	}
+	assert.NoError(t, ValidateAffiliations(affiliations))
+
+	grouped, err := table.GroupByAffiliation(affiliations)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Acme", "Globex", Unaffiliated}, grouped.Users())
+	assert.Equal(t, []int{7, 3, 1}, grouped.Row(0))
+	assert.Equal(t, []int{0, 0, 4}, grouped.Row(1))
+	assert.Equal(t, []int{1, 1, 1}, grouped.Row(2))
+	assert.Equal(t, "overview.csv", grouped.Source)
+
+	members, err := table.Members(affiliations, "Acme")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alpha", "bravo"}, members.Users())
+	assert.Equal(t, []int{2, 3, 0}, members.Row(1))
+}
+
+func Test_ValidateAffiliations(t *testing.T) {
+	february, _ := ParseMonth("2023-02")
+
+	assert.Error(t, ValidateAffiliations([]Affiliation{{Login: "alpha", Organization: "Acme"}, {Login: "ALPHA", Organization: "Globex", From: february}}))
+	assert.Error(t, ValidateAffiliations([]Affiliation{{Login: "alpha"}}))
+	assert.Error(t, ValidateAffiliations([]Affiliation{{Login: "alpha", Organization: "Acme", From: february, To: AddMonths(february, -1)}}))
+}
+
+func Test_Anonymize(t *testing.T) {
+	table, err := FromRecords([][]string{
+		{"", "2023-01", "2023-02"},
+		{"alpha", "1", "0"},
+		{"bravo", "2", "3"},
+		{"charly", "0", "3"},
+	})
+	assert.NoError(t, err)
+	table.Source = "overview.csv"
+
+	p, err := NewPseudonymizer(PseudonymNumbered, "", true, nil)
+	assert.NoError(t, err)
+	anonymized, count, err := table.Anonymize(p)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	// Numbered in descending order of contributions, in the order of the table for the same total
+	assert.Equal(t, []string{"Contributor #3", "Contributor #1", "Contributor #2"}, anonymized.Users())
+	assert.Equal(t, []int{2, 3}, anonymized.Row(1))
+	assert.Equal(t, "overview.csv", anonymized.Source)
+
+	// The pseudonyms are kept from one table to another
+	other, err := FromRecords([][]string{
+		{"", "2023-01"},
+		{"delta", "7"},
+		{"Bravo", "1"},
+	})
+	assert.NoError(t, err)
+	anonymized, _, err = other.Anonymize(p)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Contributor #4", "Contributor #1"}, anonymized.Users())
+}
+
+func Test_Anonymize_optOut(t *testing.T) {
+	table, err := FromRecords([][]string{
+		{"", "2023-01", "2023-02"},
+		{"alpha", "1", "0"},
+		{"bravo", "2", "3"},
+	})
+	assert.NoError(t, err)
+	optOut, err := NewAccountFilter([]string{"BRAVO"})
+	assert.NoError(t, err)
+
+	p, err := NewPseudonymizer(PseudonymNumbered, "", false, optOut)
+	assert.NoError(t, err)
+	assert.True(t, p.IsEnabled())
+	anonymized, count, err := table.Anonymize(p)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"alpha", "Contributor #1"}, anonymized.Users())
+
+	// Nothing to anonymize: the table is returned as is
+	p, err = NewPseudonymizer(PseudonymNumbered, "", false, nil)
+	assert.NoError(t, err)
+	assert.False(t, p.IsEnabled())
+	same, count, err := table.Anonymize(p)
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+	assert.Same(t, table, same)
+}
+
+func Test_NewPseudonymizer_hashed(t *testing.T) {
+	table, err := FromRecords([][]string{
+		{"", "2023-01"},
+		{"alpha", "1"},
+	})
+	assert.NoError(t, err)
+
+	pseudonymOf := func(salt string) string {
+		p, err := NewPseudonymizer(PseudonymHashed, salt, true, nil)
+		assert.NoError(t, err)
+		anonymized, _, err := table.Anonymize(p)
+		assert.NoError(t, err)
+		return anonymized.User(0)
+	}
+	assert.Regexp(t, `^Contributor [0-9a-f]{8}$`, pseudonymOf("pepper"))
+	assert.Equal(t, pseudonymOf("pepper"), pseudonymOf("pepper"), "The hashed pseudonyms should be stable")
+	assert.NotEqual(t, pseudonymOf("pepper"), pseudonymOf("salt"))
+
+	_, err = NewPseudonymizer(PseudonymHashed, "", true, nil)
+	assert.Error(t, err, "A salt should be required")
+	_, err = NewPseudonymizer("random", "", true, nil)
+	assert.Error(t, err, "An unknown style should be rejected")
+}
+
+func Test_CheckLongRecords(t *testing.T) {
+	tests := []struct {
+		name     string
+		records  [][]string
+		wantRule string
+	}{
+		{"happy case", [][]string{{"User", "Month", "Repository", "Label", "Count"}, {"alpha", "2023-01", "jenkinsci/jenkins", "bug;java", "2"}}, ""},
+		{"no count column", [][]string{{"month", "user"}, {"2023-01", "alpha"}}, ""},
+		{"no month column", [][]string{{"user", "repo"}, {"alpha", "jenkinsci/jenkins"}}, RuleHeader},
+		{"no contribution", [][]string{{"user", "month"}}, RuleHeader},
+		{"invalid month", [][]string{{"user", "month"}, {"alpha", "2023-13"}}, RuleMonthFormat},
+		{"invalid user", [][]string{{"user", "month"}, {"alpha beta", "2023-01"}}, RuleUser},
+		{"invalid count", [][]string{{"user", "month", "count"}, {"alpha", "2023-01", "-1"}}, RuleNegativeValue},
+		{"short line", [][]string{{"user", "month", "count"}, {"alpha", "2023-01"}}, RuleRowLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, report := CheckLongRecords(tt.records)
+			if tt.wantRule == "" {
+				assert.False(t, report.HasErrors(), "Unexpected errors: %v", report.Issues)
+				assert.NotNil(t, table)
+				return
+			}
+			assert.Nil(t, table)
+			assert.True(t, report.HasErrors())
+			assert.Equal(t, tt.wantRule, report.FirstError().(Issue).Rule)
+		})
+	}
+}
+
+func Test_FromRecords_longFormat(t *testing.T) {
+	table, err := FromRecords([][]string{
+		{"user", "month", "repo", "labels", "count"},
+		{"charly", "2023-03", "jenkinsci/jenkins", "bug", "2"},
+		{"alpha", "2023-01", "jenkinsci/jenkins", "dependencies", "3"},
+		{"alpha", "2023-01", "jenkinsci/remoting", "bug;java", "1"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alpha", "charly"}, table.Users())
+	assert.Equal(t, []string{"2023-01", "2023-02", "2023-03"}, table.MonthLabels())
+	assert.Equal(t, []int{4, 0, 0}, table.Row(0))
+	assert.Equal(t, []int{0, 0, 2}, table.Row(1))
+
+	details := table.Details()
+	assert.NotNil(t, details)
+	assert.True(t, details.HasRepos())
+	assert.True(t, details.HasLabels())
+	assert.Equal(t, []string{"bug", "java"}, details.Contributions()[2].Labels)
+
+	// A datamash pivot table has no details
+	table, err = FromRecords([][]string{{"", "2023-01"}, {"alpha", "1"}})
+	assert.NoError(t, err)
+	assert.Nil(t, table.Details())
+}
+
+func Test_ContributionFilter(t *testing.T) {
+	core := Contribution{User: "alpha", Repo: "jenkinsci/jenkins", Labels: []string{"bug", "java"}, Count: 1}
+	plugin := Contribution{User: "alpha", Repo: "jenkinsci/git-plugin", Labels: []string{"dependencies"}, Count: 1}
+	infra := Contribution{User: "alpha", Repo: "jenkins-infra/helpdesk", Count: 1}
+
+	tests := []struct {
+		name   string
+		repos  []string
+		labels []string
+		want   []bool
+	}{
+		{"no filter", nil, nil, []bool{true, true, true}},
+		{"repository glob", []string{"jenkinsci/*"}, nil, []bool{true, true, false}},
+		{"repository regardless of the case", []string{"JenkinsCI/Jenkins"}, nil, []bool{true, false, false}},
+		{"excluded repository", []string{"!*-plugin"}, nil, []bool{true, false, true}},
+		{"label", nil, []string{"java"}, []bool{true, false, false}},
+		{"excluded label", nil, []string{"!dependencies"}, []bool{true, false, true}},
+		{"repository and label", []string{"jenkinsci/*"}, []string{"!dep*"}, []bool{true, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewContributionFilter(tt.repos, tt.labels)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, []bool{filter.Match(core), filter.Match(plugin), filter.Match(infra)})
+		})
+	}
+}
+
+func Test_LongTableFilter_keepsMonths(t *testing.T) {
+	table, err := FromRecords([][]string{
+		{"user", "month", "repo"},
+		{"alpha", "2023-01", "jenkins-infra/helpdesk"},
+		{"alpha", "2023-02", "jenkins-infra/helpdesk"},
+		{"bravo", "2023-04", "jenkinsci/jenkins"},
+	})
+	assert.NoError(t, err)
+	filter, err := NewContributionFilter([]string{"jenkins-infra/*"}, nil)
+	assert.NoError(t, err)
+
+	// The months after the last matching contribution are kept (filled with 0)
+	filtered, err := table.Details().Filter(filter).Pivot()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alpha"}, filtered.Users())
+	assert.Equal(t, []string{"2023-01", "2023-02", "2023-03", "2023-04"}, filtered.MonthLabels())
+	assert.Equal(t, []int{1, 1, 0, 0}, filtered.Row(0))
+}
+
+func Test_RepoTotals(t *testing.T) {
+	table, err := FromRecords([][]string{
+		{"user", "month", "repo", "count"},
+		{"alpha", "2023-01", "jenkinsci/remoting", "5"},
+		{"alpha", "2023-02", "jenkinsci/jenkins", "2"},
+		{"alpha", "2023-03", "jenkinsci/remoting", "1"},
+		{"alpha-old", "2023-02", "jenkinsci/jenkins", "2"},
+		{"bravo", "2023-03", "jenkinsci/jenkins", "3"},
+		{"renovate", "2023-03", "jenkinsci/jenkins", "9"},
+	})
+	assert.NoError(t, err)
+	february, _ := ParseMonth("2023-02")
+	march, _ := ParseMonth("2023-03")
+
+	aliases := []Alias{{Login: "alpha-old", Canonical: "alpha"}}
+	totals := table.Details().RepoTotals(february, march, func(user string, month time.Time) (string, bool) {
+		return CanonicalLogin(aliases, user, month), user != "renovate"
+	})
+	assert.Equal(t, map[string][]RepoTotal{
+		"alpha": {{Repo: "jenkinsci/jenkins", Total: 4}, {Repo: "jenkinsci/remoting", Total: 1}},
+		"bravo": {{Repo: "jenkinsci/jenkins", Total: 3}},
+	}, totals)
+}
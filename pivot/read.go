@@ -0,0 +1,229 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import (
+	"encoding/csv"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// The GitHub user validation regexp (see https://stackoverflow.com/questions/58726546/github-username-convention-using-regex)
+// should be regexp.Compile(`^[a-zA-Z0-9]+(?:-[a-zA-Z0-9]+)*$`). But the dataset contains "invalid" data: username ending with a "-" or
+// a double "-" in the name. These are accepted but reported as warnings.
+// The accounts of the GitHub Apps have a "[bot]" suffix (e.g. "dependabot[bot]").
+var userRegexp = regexp.MustCompile(`^[a-zA-Z0-9\-]+(?:\[bot\])?$`)
+var strictUserRegexp = regexp.MustCompile(`^[a-zA-Z0-9]+(?:-[a-zA-Z0-9]+)*(?:\[bot\])?$`)
+
+// The suffix of the logins of the GitHub Apps (not counted in the length of the login)
+const botSuffix = "[bot]"
+
+// Load reads and validates the pivot table stored in the given CSV file
+func Load(fileName string) (*PivotTable, error) {
+	table, report := CheckFile(fileName)
+	if report.HasErrors() {
+		return nil, report.FirstError()
+	}
+	return table, nil
+}
+
+// Read reads and validates a pivot table in the datamash CSV format
+func Read(r io.Reader) (*PivotTable, error) {
+	table, report := Check(r)
+	if report.HasErrors() {
+		return nil, report.FirstError()
+	}
+	return table, nil
+}
+
+// FromRecords validates the raw CSV records (header included) and converts them to a pivot table
+func FromRecords(records [][]string) (*PivotTable, error) {
+	table, report := CheckRecords(records)
+	if report.HasErrors() {
+		return nil, report.FirstError()
+	}
+	return table, nil
+}
+
+// CheckFile validates the pivot table stored in the given CSV file (see Open), collecting every issue found.
+// The table is nil if the report contains errors.
+func CheckFile(fileName string) (*PivotTable, *Report) {
+	source := SourceName(fileName)
+	f, err := Open(fileName)
+	if err != nil {
+		report := &Report{Source: source}
+		report.Add(0, 0, SeverityError, RuleCSV, "Unable to read input file %s: %v", source, err)
+		return nil, report
+	}
+	defer f.Close()
+
+	table, report := Check(f)
+	report.Source = source
+	if table != nil {
+		table.Source = source
+		if table.details != nil {
+			table.details.Source = source
+		}
+	}
+	return table, report
+}
+
+// Check validates a pivot table in the datamash CSV format, collecting every issue found.
+// The table is nil if the report contains errors.
+func Check(r io.Reader) (*PivotTable, *Report) {
+	csvReader := csv.NewReader(r)
+	// Row length is checked while validating to report every faulty row
+	csvReader.FieldsPerRecord = -1
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, csvErrorReport(err)
+	}
+	return CheckRecords(records)
+}
+
+// CheckRecords validates the raw CSV records (header included), collecting every issue found.
+// The table is nil if the report contains errors.
+func CheckRecords(records [][]string) (*PivotTable, *Report) {
+	report := &Report{}
+
+	if len(records) == 0 {
+		report.Add(0, 0, SeverityError, RuleHeader, "The pivot table is empty")
+		return nil, report
+	}
+
+	header := records[0]
+	if header[0] != "" && IsLongFormat(header) {
+		return checkLongPivot(records)
+	}
+	if header[0] != "" {
+		report.Add(1, 1, SeverityError, RuleHeader, "Not the expected first column name (should be empty)")
+	}
+
+	var months []time.Time
+	for i, columnName := range header[1:] {
+		month, err := ParseMonth(columnName)
+		if err != nil {
+			report.Add(1, i+2, SeverityError, RuleMonthFormat, "Column header %s is not of the expected format (YYYY-MM)", columnName)
+		}
+		months = append(months, month)
+	}
+	validateMonthSequence(report, months)
+
+	var users []string
+	var values [][]int
+	seenUsers := make(map[string]int)
+	for i, record := range records[1:] {
+		lineNbr := i + 2
+
+		if len(record) != len(header) {
+			report.Add(lineNbr, 0, SeverityError, RuleRowLength, "Line has %d columns while the header defines %d", len(record), len(header))
+			continue
+		}
+
+		user := record[0]
+		validateUser(report, user, lineNbr)
+		if previousLine, found := seenUsers[user]; found {
+			report.Add(lineNbr, 1, SeverityError, RuleDuplicateUser, "User \"%s\" is already defined at line %d", user, previousLine)
+		}
+		seenUsers[user] = lineNbr
+
+		row := make([]int, len(months))
+		for ii, cell := range record[1:] {
+			row[ii], _ = validateValue(report, cell, lineNbr, ii+2)
+		}
+
+		users = append(users, user)
+		values = append(values, row)
+	}
+
+	if report.HasErrors() {
+		return nil, report
+	}
+
+	months, values = fillMonthGaps(months, values)
+	table, err := New(months, users, values)
+	if err != nil {
+		report.Add(0, 0, SeverityError, RuleCSV, "%v", err)
+		return nil, report
+	}
+	return table, report
+}
+
+// Validates a long-format table and converts it to a pivot table keeping its details
+func checkLongPivot(records [][]string) (*PivotTable, *Report) {
+	details, report := CheckLongRecords(records)
+	if details == nil {
+		return nil, report
+	}
+	table, err := details.Pivot()
+	if err != nil {
+		report.Add(0, 0, SeverityError, RuleCSV, "%v", err)
+		return nil, report
+	}
+	return table, report
+}
+
+// Inserts a zero filled column for every month missing between the first and the last one.
+// The months must be valid, unique and in chronological order.
+func fillMonthGaps(months []time.Time, values [][]int) ([]time.Time, [][]int) {
+	if len(months) == 0 {
+		return months, values
+	}
+	nbrOfMonths := MonthsBetween(months[0], months[len(months)-1]) + 1
+	if nbrOfMonths == len(months) {
+		return months, values
+	}
+
+	filledMonths := make([]time.Time, nbrOfMonths)
+	for i := range filledMonths {
+		filledMonths[i] = AddMonths(months[0], i)
+	}
+	filledValues := make([][]int, len(values))
+	for i, row := range values {
+		filledValues[i] = make([]int, nbrOfMonths)
+		for ii, value := range row {
+			filledValues[i][MonthsBetween(months[0], months[ii])] = value
+		}
+	}
+	return filledMonths, filledValues
+}
+
+// IsValidUser checks whether the login follows the (relaxed) GitHub rules
+func IsValidUser(user string) bool {
+	if user == DeletedUser {
+		return true
+	}
+	login := strings.TrimSuffix(user, botSuffix)
+	return len(login) < 40 && len(login) > 0 && userRegexp.MatchString(user)
+}
+
+// Write writes the table in the datamash CSV format
+func (t *PivotTable) Write(w io.Writer) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.WriteAll(t.Records()); err != nil {
+		return err
+	}
+	return csvWriter.Error()
+}
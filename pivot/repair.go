@@ -0,0 +1,227 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fix describes a modification done while repairing a pivot table
+type Fix struct {
+	// Line and column of the original file (starting at 1). Zero when not bound to a line or a column.
+	Line        int    `json:"line"`
+	Column      int    `json:"column"`
+	Description string `json:"description"`
+}
+
+// String formats the fix with its coordinates
+func (f Fix) String() string {
+	return withCoordinates(f.Line, f.Column, f.Description)
+}
+
+// RepairFile loads the CSV file (see Open) and repairs the common defects of the pivot table (see RepairRecords)
+func RepairFile(fileName string) (*PivotTable, *Report) {
+	source := SourceName(fileName)
+	f, err := Open(fileName)
+	if err != nil {
+		report := &Report{Source: source}
+		report.Add(0, 0, SeverityError, RuleCSV, "Unable to read input file %s: %v", source, err)
+		return nil, report
+	}
+	defer f.Close()
+
+	table, report := Repair(f)
+	report.Source = source
+	if table != nil {
+		table.Source = source
+	}
+	return table, report
+}
+
+// Repair reads a pivot table in the datamash CSV format and repairs its common defects (see RepairRecords)
+func Repair(r io.Reader) (*PivotTable, *Report) {
+	csvReader := csv.NewReader(r)
+	csvReader.FieldsPerRecord = -1
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, csvErrorReport(err)
+	}
+	return RepairRecords(records)
+}
+
+// RepairRecords normalizes the following defects of the raw CSV records:
+//   - leading and trailing whitespace is trimmed from every cell,
+//   - blank values are replaced by 0,
+//   - the rows of duplicate users are summed,
+//   - duplicate month columns are summed,
+//   - the month columns are sorted chronologically,
+//   - missing months are inserted as zero filled columns.
+//
+// Every change is listed in the Fixes of the returned report. The remaining issues
+// (those that can't be repaired) are reported as usual, with coordinates relative to
+// the repaired table, and the table is nil if any is fatal.
+func RepairRecords(records [][]string) (*PivotTable, *Report) {
+	report := &Report{}
+
+	if len(records) == 0 {
+		report.Add(0, 0, SeverityError, RuleHeader, "The pivot table is empty")
+		return nil, report
+	}
+
+	cleaned := trimRecords(records, report)
+
+	// The values can only be consolidated if every cell is readable
+	months, isReadable := parseHeaderMonths(cleaned[0])
+	for _, record := range cleaned[1:] {
+		if len(record) != len(cleaned[0]) {
+			isReadable = false
+			break
+		}
+		for _, cell := range record[1:] {
+			if _, err := strconv.Atoi(cell); err != nil {
+				isReadable = false
+			}
+		}
+	}
+	if !isReadable {
+		return checkRepaired(cleaned, report)
+	}
+
+	repaired := consolidateRecords(cleaned, months, report)
+	return checkRepaired(repaired, report)
+}
+
+// Trims every cell and replaces the blank values by 0
+func trimRecords(records [][]string, report *Report) [][]string {
+	cleaned := make([][]string, len(records))
+	for i, record := range records {
+		cleaned[i] = make([]string, len(record))
+		for ii, cell := range record {
+			trimmed := strings.TrimSpace(cell)
+			if trimmed != cell && trimmed != "" {
+				report.Fixes = append(report.Fixes, Fix{i + 1, ii + 1, "Trimmed whitespace around \"" + trimmed + "\""})
+			}
+			if trimmed == "" && i > 0 && ii > 0 {
+				trimmed = "0"
+				report.Fixes = append(report.Fixes, Fix{i + 1, ii + 1, "Replaced blank value by 0"})
+			}
+			cleaned[i][ii] = trimmed
+		}
+	}
+	return cleaned
+}
+
+// Parses the month headers. Returns false if any of them is invalid.
+func parseHeaderMonths(header []string) ([]time.Time, bool) {
+	var months []time.Time
+	for _, columnName := range header[1:] {
+		month, err := ParseMonth(columnName)
+		if err != nil {
+			return nil, false
+		}
+		months = append(months, month)
+	}
+	return months, true
+}
+
+// Sums duplicate users and months, sorts the months and fills the missing ones
+func consolidateRecords(records [][]string, months []time.Time, report *Report) [][]string {
+	if len(months) == 0 {
+		return records
+	}
+
+	// Compute the chronological and contiguous list of months
+	sortedMonths := append([]time.Time(nil), months...)
+	sort.Slice(sortedMonths, func(i, j int) bool { return sortedMonths[i].Before(sortedMonths[j]) })
+	if !sort.SliceIsSorted(months, func(i, j int) bool { return months[i].Before(months[j]) }) {
+		report.Fixes = append(report.Fixes, Fix{1, 0, "Sorted the month columns chronologically"})
+	}
+
+	firstMonth := sortedMonths[0]
+	nbrOfMonths := MonthsBetween(firstMonth, sortedMonths[len(sortedMonths)-1]) + 1
+
+	seenColumns := make(map[time.Time]int)
+	for i, month := range months {
+		if previous, found := seenColumns[month]; found {
+			report.Fixes = append(report.Fixes, Fix{1, i + 2, "Summed duplicate month " + FormatMonth(month) + " into column " + strconv.Itoa(previous+2)})
+			continue
+		}
+		seenColumns[month] = i
+	}
+	for i := 0; i < nbrOfMonths; i++ {
+		month := AddMonths(firstMonth, i)
+		if _, found := seenColumns[month]; !found {
+			report.Fixes = append(report.Fixes, Fix{1, 0, "Inserted missing month " + FormatMonth(month) + " (filled with 0)"})
+		}
+	}
+
+	// Sum the values per user and month
+	var users []string
+	userRows := make(map[string]int)
+	userLines := make(map[string]int)
+	var values [][]int
+	for i, record := range records[1:] {
+		user := record[0]
+		row, found := userRows[user]
+		if found {
+			report.Fixes = append(report.Fixes, Fix{i + 2, 1, "Summed duplicate user \"" + user + "\" into line " + strconv.Itoa(userLines[user])})
+		} else {
+			row = len(users)
+			users = append(users, user)
+			userRows[user] = row
+			userLines[user] = i + 2
+			values = append(values, make([]int, nbrOfMonths))
+		}
+		for ii, cell := range record[1:] {
+			value, _ := strconv.Atoi(cell)
+			values[row][MonthsBetween(firstMonth, months[ii])] += value
+		}
+	}
+
+	// Convert back to records so that the result goes through the standard validation
+	header := []string{records[0][0]}
+	for i := 0; i < nbrOfMonths; i++ {
+		header = append(header, FormatMonth(AddMonths(firstMonth, i)))
+	}
+	repaired := [][]string{header}
+	for i, user := range users {
+		record := []string{user}
+		for _, value := range values[i] {
+			record = append(record, strconv.Itoa(value))
+		}
+		repaired = append(repaired, record)
+	}
+	return repaired
+}
+
+// Validates the repaired records, keeping the fixes done so far
+func checkRepaired(records [][]string, repairReport *Report) (*PivotTable, *Report) {
+	table, report := CheckRecords(records)
+	report.Fixes = repairReport.Fixes
+	return table, report
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import (
+	"sort"
+	"time"
+)
+
+// Weighted associates a table with the weight of its contributions in a combined score
+type Weighted struct {
+	Table  *PivotTable
+	Weight float64
+}
+
+// Score is the weighted combination of the contributions of a user in several tables
+type Score struct {
+	User  string
+	Score float64
+	// Totals are the contributions of the user in each table (in the order of the weighted tables)
+	Totals []int
+}
+
+// CombinedScores sums, for every user of any of the tables, the weighted contributions between
+// two calendar months (both included). A user missing from a table has no contribution in it.
+// The result is sorted by descending score, then by user.
+func CombinedScores(from time.Time, to time.Time, tables ...Weighted) []Score {
+	var scores []Score
+	userScores := make(map[string]int)
+	for i, weighted := range tables {
+		first, last := weighted.Table.ColumnsBetween(from, to)
+		for row, user := range weighted.Table.users {
+			index, found := userScores[user]
+			if !found {
+				index = len(scores)
+				userScores[user] = index
+				scores = append(scores, Score{User: user, Totals: make([]int, len(tables))})
+			}
+			if first != -1 {
+				scores[index].Totals[i] = weighted.Table.Sum(row, first, last)
+			}
+		}
+	}
+
+	for i := range scores {
+		for ii, total := range scores[i].Totals {
+			scores[i].Score += float64(total) * tables[ii].Weight
+		}
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].User < scores[j].User
+	})
+	return scores
+}
+
+// TopScores returns the first topSize entries of a sorted list of scores (ex aequo included, see Top)
+func TopScores(scores []Score, topSize int) []Score {
+	if topSize >= len(scores) {
+		return scores
+	}
+	if topSize <= 0 {
+		return nil
+	}
+	end := topSize
+	for end < len(scores) && scores[end].Score == scores[topSize-1].Score {
+		end++
+	}
+	return scores[:end]
+}
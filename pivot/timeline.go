@@ -0,0 +1,122 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import (
+	"sort"
+	"time"
+)
+
+// Timeline is the membership of the users in the top users of a window sliding month by month
+type Timeline struct {
+	// Months are the last months of the windows
+	Months []time.Time
+	// Users are the users being in the top at least once, by order of first entry (then rank)
+	Users []string
+	// Ranks are the ranks of the users (in the order of Users) for every window, 0 if not in the top
+	Ranks [][]int
+}
+
+// Membership summarizes the presence of a user in the top users of a timeline
+type Membership struct {
+	User        string
+	MonthsInTop int
+	BestRank    int
+	// Entries are the months where the user entered the top (including the first month of the timeline)
+	Entries []time.Time
+	// Exits are the first months where the user was no longer in the top
+	Exits []time.Time
+}
+
+// TopTimeline computes, for every window of "window" months ending between two columns (both included),
+// the top users and their rank (ex aequo users share the same rank). Windows starting before the first
+// month are skipped. A window of 0 accumulates all the months since the first one.
+func (t *PivotTable) TopTimeline(from int, to int, window int, topSize int) *Timeline {
+	timeline := &Timeline{}
+	userRows := make(map[string]int)
+
+	for end := from; end <= to; end++ {
+		start := 0
+		if window > 0 {
+			start = end - window + 1
+			if start < 0 {
+				continue
+			}
+		}
+		monthIndex := len(timeline.Months)
+		timeline.Months = append(timeline.Months, t.months[end])
+		for i := range timeline.Ranks {
+			timeline.Ranks[i] = append(timeline.Ranks[i], 0)
+		}
+
+		top := Top(t.Totals(start, end), topSize)
+		// Users with the same total are sorted by name for a stable order of first entry
+		sort.SliceStable(top, func(i, j int) bool {
+			if top[i].Total != top[j].Total {
+				return top[i].Total > top[j].Total
+			}
+			return top[i].User < top[j].User
+		})
+		rank := 0
+		for i, total := range top {
+			if total.Total == 0 {
+				break
+			}
+			if i == 0 || total.Total != top[i-1].Total {
+				rank = i + 1
+			}
+			row, found := userRows[total.User]
+			if !found {
+				row = len(timeline.Users)
+				userRows[total.User] = row
+				timeline.Users = append(timeline.Users, total.User)
+				timeline.Ranks = append(timeline.Ranks, make([]int, monthIndex+1))
+			}
+			timeline.Ranks[row][monthIndex] = rank
+		}
+	}
+	return timeline
+}
+
+// Memberships summarizes the presence of every user of the timeline in the top users
+func (tl *Timeline) Memberships() []Membership {
+	memberships := make([]Membership, len(tl.Users))
+	for row, user := range tl.Users {
+		membership := Membership{User: user}
+		for month, rank := range tl.Ranks[row] {
+			wasInTop := month > 0 && tl.Ranks[row][month-1] > 0
+			if rank > 0 {
+				membership.MonthsInTop++
+				if membership.BestRank == 0 || rank < membership.BestRank {
+					membership.BestRank = rank
+				}
+				if !wasInTop {
+					membership.Entries = append(membership.Entries, tl.Months[month])
+				}
+			} else if wasInTop {
+				membership.Exits = append(membership.Exits, tl.Months[month])
+			}
+		}
+		memberships[row] = membership
+	}
+	return memberships
+}
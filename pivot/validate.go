@@ -0,0 +1,239 @@
+/*
+Copyright © 2024 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package pivot
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Severity tells whether a validation issue prevents the table from being processed
+type Severity uint8
+
+const (
+	SeverityWarning Severity = iota + 1
+	SeverityError
+)
+
+// Rules checked while validating a pivot table
+const (
+	RuleCSV            = "csv"             // the file can't be parsed as CSV
+	RuleHeader         = "header"          // the first header column must be empty
+	RuleMonthFormat    = "month-format"    // month headers must be "YYYY-MM"
+	RuleDuplicateMonth = "duplicate-month" // a month must appear only once
+	RuleMonthOrder     = "month-order"     // months must be in chronological order
+	RuleMonthGap       = "month-gap"       // months should be contiguous (missing ones are filled with 0)
+	RuleRowLength      = "row-length"      // every row must have as many columns as the header
+	RuleUser           = "user"            // logins must follow the (relaxed) GitHub rules
+	RuleUserConvention = "user-convention" // logins should follow the strict GitHub rules
+	RuleDuplicateUser  = "duplicate-user"  // a login must appear only once
+	RuleValue          = "value"           // values must be integers
+	RuleNegativeValue  = "negative-value"  // values must not be negative
+	RuleAliasCollision = "alias-collision" // an alias and its canonical login should not both have a row
+)
+
+// String returns the lower case name of the severity
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText renders the severity by name (used for the JSON output)
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses a severity name
+func (s *Severity) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "warning":
+		*s = SeverityWarning
+	case "error":
+		*s = SeverityError
+	default:
+		return fmt.Errorf("unknown severity \"%s\"", string(text))
+	}
+	return nil
+}
+
+// Issue is a single problem found while validating a pivot table
+type Issue struct {
+	// Line in the file (starting at 1). Zero when the issue is not bound to a line.
+	Line int `json:"line"`
+	// Column in the line (starting at 1). Zero when the issue concerns the whole line.
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	Message  string   `json:"message"`
+}
+
+// Error formats the issue with its coordinates
+func (i Issue) Error() string {
+	return withCoordinates(i.Line, i.Column, i.Message)
+}
+
+// Report collects all the issues found while validating a pivot table
+type Report struct {
+	Source string  `json:"source"`
+	Issues []Issue `json:"issues"`
+	// Fixes lists the changes done when the table was repaired (see Repair)
+	Fixes []Fix `json:"fixes,omitempty"`
+}
+
+// Add records a new issue
+func (r *Report) Add(line int, column int, severity Severity, rule string, format string, a ...interface{}) {
+	r.Issues = append(r.Issues, Issue{
+		Line:     line,
+		Column:   column,
+		Severity: severity,
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+// NbrOfErrors returns the number of fatal issues
+func (r *Report) NbrOfErrors() int {
+	return r.count(SeverityError)
+}
+
+// NbrOfWarnings returns the number of non fatal issues
+func (r *Report) NbrOfWarnings() int {
+	return r.count(SeverityWarning)
+}
+
+// HasErrors is true if at least one issue prevents the table from being processed
+func (r *Report) HasErrors() bool {
+	return r.NbrOfErrors() > 0
+}
+
+// FirstError returns the first fatal issue, or nil if there is none
+func (r *Report) FirstError() error {
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return issue
+		}
+	}
+	return nil
+}
+
+func (r *Report) count(severity Severity) int {
+	count := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// Prefixes a message with its line and column (when available)
+func withCoordinates(line int, column int, message string) string {
+	switch {
+	case line == 0:
+		return message
+	case column == 0:
+		return fmt.Sprintf("line %d: %s", line, message)
+	default:
+		return fmt.Sprintf("line %d, column %d: %s", line, column, message)
+	}
+}
+
+// Converts a CSV reading error to a report
+func csvErrorReport(err error) *Report {
+	report := &Report{}
+	if parseErr, ok := err.(*csv.ParseError); ok {
+		report.Add(parseErr.Line, parseErr.Column, SeverityError, RuleCSV, "%v", parseErr.Err)
+	} else {
+		report.Add(0, 0, SeverityError, RuleCSV, "Unexpected error loading the pivot table: %v", err)
+	}
+	return report
+}
+
+// Checks that the months are unique, in chronological order and contiguous.
+// Invalid months (zero time) have already been reported: the sequence is not checked across them.
+func validateMonthSequence(report *Report, months []time.Time) {
+	seenMonths := make(map[time.Time]int)
+	var previous time.Time
+	for i, month := range months {
+		columnNbr := i + 2
+		if month.IsZero() {
+			previous = time.Time{}
+			continue
+		}
+		if previousColumn, found := seenMonths[month]; found {
+			report.Add(1, columnNbr, SeverityError, RuleDuplicateMonth, "Month %s is already defined in column %d", FormatMonth(month), previousColumn)
+			continue
+		}
+		seenMonths[month] = columnNbr
+
+		if !previous.IsZero() {
+			switch gap := MonthsBetween(previous, month); {
+			case gap < 0:
+				report.Add(1, columnNbr, SeverityError, RuleMonthOrder, "Month %s is not in chronological order (follows %s)", FormatMonth(month), FormatMonth(previous))
+				continue
+			case gap == 2:
+				report.Add(1, columnNbr, SeverityWarning, RuleMonthGap, "Month %s is missing (filled with 0)", FormatMonth(AddMonths(previous, 1)))
+			case gap > 2:
+				report.Add(1, columnNbr, SeverityWarning, RuleMonthGap, "Months %s to %s are missing (filled with 0)", FormatMonth(AddMonths(previous, 1)), FormatMonth(AddMonths(month, -1)))
+			}
+		}
+		previous = month
+	}
+}
+
+// Checks the user login, reporting whether it is invalid or just unconventional
+func validateUser(report *Report, user string, lineNbr int) {
+	validateUserAt(report, user, lineNbr, 1)
+}
+
+// Checks a login found in the given column
+func validateUserAt(report *Report, user string, lineNbr int, columnNbr int) {
+	if !IsValidUser(user) {
+		report.Add(lineNbr, columnNbr, SeverityError, RuleUser, "User \"%s\" does not follow GitHub rules", user)
+		return
+	}
+	if user != DeletedUser && !strictUserRegexp.MatchString(user) {
+		report.Add(lineNbr, columnNbr, SeverityWarning, RuleUserConvention, "User \"%s\" does not follow the strict GitHub naming convention", user)
+	}
+}
+
+// Checks a data cell, reporting why it can't be used
+func validateValue(report *Report, cell string, lineNbr int, columnNbr int) (value int, isValid bool) {
+	value, err := strconv.Atoi(cell)
+	if err != nil {
+		report.Add(lineNbr, columnNbr, SeverityError, RuleValue, "Value \"%s\" isn't an integer", cell)
+		return 0, false
+	}
+	if value < 0 {
+		report.Add(lineNbr, columnNbr, SeverityError, RuleNegativeValue, "Value \"%s\" is negative", cell)
+		return 0, false
+	}
+	return value, true
+}